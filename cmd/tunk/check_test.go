@@ -63,6 +63,17 @@ func TestCheckCommit(t *testing.T) {
 			},
 			gitPath: gitPath,
 		},
+		{
+			name: "multiple-scopes",
+			ops: []testOperation{
+				{Commit: "initial commit"},
+				{Tag: "v0.1.0"},
+				{TunkArgs: strs("--check-commit", "feat(api,cli): cool thing", "--allowed-scope", "api", "--allowed-scope", "cli")},
+				{TunkArgs: strs("--check-commit", "feat(api, cli): cool thing", "--allowed-scope", "api", "--allowed-scope", "cli")},
+				{TunkArgs: strs("--check-commit", "feat(api,nope): cool thing", "--allowed-scope", "api", "--allowed-scope", "cli"), ShouldFail: true},
+			},
+			gitPath: gitPath,
+		},
 		{
 			name: "fail-disallowed-type",
 			ops: []testOperation{
@@ -73,6 +84,65 @@ func TestCheckCommit(t *testing.T) {
 			},
 			gitPath: gitPath,
 		},
+		{
+			name: "scope-allowed-type",
+			ops: []testOperation{
+				{Commit: "initial commit"},
+				{Tag: "v0.1.0"},
+				{TunkArgs: strs("--check-commit", "perf: cool thing")},
+				{TunkArgs: strs("--check-commit", "fix(sdk): cool thing")},
+				{TunkArgs: strs("--check-commit", "perf(sdk): cool thing"), ShouldFail: true},
+			},
+			gitPath: gitPath,
+		},
+		{
+			name: "lint",
+			ops: []testOperation{
+				{Commit: "initial commit"},
+				{Tag: "v0.1.0"},
+				{Commit: "feat: cool thing.\n\nSigned-off-by: tunk-test <tunk-test@example.com>"},
+				{TunkArgs: strs("--check")},
+				{TunkArgs: strs("--check-commit", "feat: cool thing"), ShouldFail: true},
+				{TunkArgs: strs("--check-commit", "feat: cool thing\n\nSigned-off-by: tunk-test <tunk-test@example.com>")},
+			},
+			gitPath: gitPath,
+		},
+		{
+			name: "lint-body-leading-blank",
+			ops: []testOperation{
+				{Commit: "initial commit"},
+				{Tag: "v0.1.0"},
+				{Commit: "feat: cool thing\n\nwith a body"},
+				{TunkArgs: strs("--check")},
+				{Commit: "feat: cool thing\nwith a body right after the subject"},
+				{TunkArgs: strs("--check"), ShouldFail: true},
+			},
+			gitPath: gitPath,
+		},
+		{
+			name: "lint-subject-line",
+			ops: []testOperation{
+				{Commit: "initial commit"},
+				{Tag: "v0.1.0"},
+				{Commit: "feat: cool thing\nwith a body right after the subject"},
+				{TunkArgs: strs("--check")},
+				{Commit: "feat: cool thing that is too long to be a subject"},
+				{TunkArgs: strs("--check"), ShouldFail: true},
+			},
+			gitPath: gitPath,
+		},
+		{
+			name: "ignore",
+			ops: []testOperation{
+				{Commit: "initial commit"},
+				{Tag: "v0.1.0"},
+				{Commit: "feat: cool thing"},
+				{Commit: "Merge branch 'cool'"},
+				{TunkArgs: strs("--check")},
+				{TunkArgs: strs("--check-commit", "Merge branch 'cool'")},
+			},
+			gitPath: gitPath,
+		},
 		{
 			name: "fail-flag",
 			ops: []testOperation{
@@ -88,6 +88,26 @@ func TestTunkCIMode(t *testing.T) {
 			environ: strs("GIT_TOKEN=coolpass"),
 		},
 
+		{
+			gitPath: gitPath,
+			name:    "alias-tags",
+			passwd:  "coolpass",
+			preOps: []testOperation{
+				{Commit: "initial commit"},
+				{Tag: "v0.1.0"},
+				{GitArgs: strs("push", "--follow-tags", "origin", "master")},
+			},
+			ops: []testOperation{
+				{Commit: "feat: a"},
+				{GitArgs: strs("push", "origin", "master")},
+				{TunkArgs: strs("--ci", "-c", filepath.Join(ciSourceDir, "alias-tags", "tunk.yaml"))},
+				{Commit: "fix: b"},
+				{GitArgs: strs("push", "origin", "master")},
+				{TunkArgs: strs("--ci", "-c", filepath.Join(ciSourceDir, "alias-tags", "tunk.yaml"))},
+			},
+			environ: strs("GIT_TOKEN=coolpass"),
+		},
+
 		{
 			gitPath: gitPath,
 			name:    "not-trunk",
@@ -28,6 +28,10 @@ func TestInvalidConfig(t *testing.T) {
 			name: "patch-major",
 			args: strs("--patch", "--major"),
 		},
+		{
+			name: "graduate-major",
+			args: strs("--graduate", "--major"),
+		},
 	}
 
 	for _, tc := range tcs {
@@ -92,6 +96,11 @@ func TestLoadConfig(t *testing.T) {
 			args:     strs("--branch", "bork"),
 			expect:   newCfg(&Conf{Branches: strs("bork")}),
 		},
+		{
+			name:     "zero-major",
+			tunkYAML: `zero_major: true`,
+			expect:   newCfg(&Conf{ZeroMajor: true}),
+		},
 		{
 			name:     "policies",
 			tunkYAML: `policies: [conventional-lax]`,
@@ -105,6 +114,26 @@ func TestLoadConfig(t *testing.T) {
 				return c
 			}),
 		},
+		{
+			name: "policies-extends",
+			tunkYAML: `policies: [ours]
+custom_policies:
+  - name: ours
+    extends: conventional-lax
+    commit_types:
+      chore: PATCH`,
+			expect: newCfg(&Conf{
+				Policies: strs("ours"),
+				CustomPolicies: []config.Policy{
+					{Name: "ours", Extends: "conventional-lax", CommitTypes: map[string]string{"chore": "PATCH"}},
+				},
+			}),
+		},
+		{
+			name:       "policies-extends-missing",
+			tunkYAML:   `{policies: [ours], custom_policies: [{name: ours, extends: nope}]}`,
+			shouldFail: true,
+		},
 		{
 			name:     "policies-unset-override",
 			tunkYAML: `policies: []`,
@@ -177,6 +206,8 @@ func TestLoadConfig(t *testing.T) {
 			compareBool(t, "major", cfg.Major, expectCfg.Major)
 			compareBool(t, "minor", cfg.Minor, expectCfg.Minor)
 			compareBool(t, "patch", cfg.Patch, expectCfg.Patch)
+			compareBool(t, "graduate", cfg.Graduate, expectCfg.Graduate)
+			compareBool(t, "zero_major", cfg.ZeroMajor, expectCfg.ZeroMajor)
 			compareStrings(t, "branches", cfg.Branches, expectCfg.Branches)
 			compareStrings(t, "release_scopes", cfg.ReleaseScopes, expectCfg.ReleaseScopes)
 			compareStrings(t, "policies", cfg.Policies, expectCfg.Policies)
@@ -249,6 +280,7 @@ func comparePolicies(t testing.TB, name string, got, expect []config.Policy) {
 	for i, pol := range got {
 		expectPol := expect[i]
 		compareString(t, "name", pol.Name, expectPol.Name)
+		compareString(t, "extends", pol.Extends, expectPol.Extends)
 		compareString(t, "subject_regex", pol.SubjectRE, expectPol.SubjectRE)
 		compareString(t, "body_annotation_start_regex", pol.BodyAnnotationStartRE, expectPol.BodyAnnotationStartRE)
 		compareStrings(t, "breaking_change_annotations", pol.BreakingChangeTypes, expectPol.BreakingChangeTypes)
@@ -5,8 +5,10 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/ghodss/yaml"
 	"github.com/imdario/mergo"
@@ -46,6 +48,8 @@ func run(rawArgs []string) error {
 	var debugConfig string
 	var printConfig bool
 	var printLatest bool
+	var noBuildMetadata bool
+	var promote bool
 	var viewPolicy bool
 	flags := pflag.NewFlagSet("tunk", pflag.ContinueOnError)
 	flags.BoolVarP(&help, "help", "h", false, "show help")
@@ -55,6 +59,9 @@ func run(rawArgs []string) error {
 	flags.BoolVar(&cfg.Major, "major", false, "bump major version")
 	flags.BoolVar(&cfg.Minor, "minor", false, "bump minor version")
 	flags.BoolVar(&cfg.Patch, "patch", false, "bump patch version")
+	flags.BoolVar(&cfg.Graduate, "graduate", false, "bump a 0.x version to 1.0.0")
+	flags.BoolVar(&cfg.AllowMajor, "allow-major", false, "approve major releases decided by policy")
+	flags.BoolVar(&promote, "promote", false, "tag a release candidate's commit with its final version")
 	flags.BoolVar(&cfg.InCI, "ci", false, "Run in CI mode")
 	flags.BoolVarP(&readStats, "stats", "S", false, "print repository stats (with top tens)")
 	flags.BoolVarP(&readAllStats, "stats-all", "A", false, "print all repository stats")
@@ -77,6 +84,7 @@ func run(rawArgs []string) error {
 	flags.StringVarP(&cfgFile, "config", "c", "", "specify config `file`")
 	flags.BoolVar(&printConfig, "print-default-config", false, "Print default configuration and exit")
 	flags.BoolVar(&printLatest, "latest", false, "Print latest version and exit")
+	flags.BoolVar(&noBuildMetadata, "no-build-metadata", false, "Print latest version without build metadata")
 	flags.StringVar(&debugConfig, "debug-config", "", "Write configuration to `file` and exit")
 
 	if err := flags.Parse(rawArgs); err != nil {
@@ -176,6 +184,10 @@ func run(rawArgs []string) error {
 		return nil
 	}
 
+	if len(args) > 0 && args[0] == "semver" {
+		return runSemverCommand(cfg, args[1:])
+	}
+
 	var rc string
 	if len(args) > 0 {
 		rc = args[0]
@@ -189,6 +201,10 @@ func run(rawArgs []string) error {
 	}
 	ctx := context.Background()
 
+	if len(args) > 0 && args[0] == "policy" {
+		return runPolicyCommand(ctx, cfg, rnr, args[1:])
+	}
+
 	if readStats || readAllStats {
 		stats, err := rnr.Stats(ctx)
 		if err != nil {
@@ -229,17 +245,18 @@ func run(rawArgs []string) error {
 	istty := isatty.IsTerminal(stdoutfd)
 
 	if printLatest {
-		latest, err := rnr.LatestRelease(ctx, cfg.Scope, rc)
+		tag, latest, err := rnr.LatestReleaseTag(ctx, cfg.Scope, rc)
 		if err != nil {
 			return err
 		}
-		tagTmpl, err := commit.NewTag(cfg.TagTemplate)
-		if err != nil {
-			return err
-		}
-		tag, err := runner.RenderTag(cfg, tagTmpl, &commit.Version{Version: latest})
-		if err != nil {
-			return err
+		if noBuildMetadata {
+			build, err := rnr.BuildMetadata(cfg.Scope, latest)
+			if err != nil {
+				return err
+			}
+			if len(build) > 0 {
+				tag = strings.Replace(tag, "+"+strings.Join(build, "."), "", 1)
+			}
 		}
 		if cfg.Quiet || !istty {
 			fmt.Fprintf(cfg.Term.Stdout, "%s", tag)
@@ -253,13 +270,27 @@ func run(rawArgs []string) error {
 		return err
 	}
 
-	tag, err := commit.NewTag(cfg.TagTemplate)
+	tag, err := commit.NewTagFromConfig(cfg)
 	if err != nil {
 		return err
 	}
 
-	versions, err := rnr.Analyze(ctx, rc)
-	if err != nil {
+	var versions []*commit.Version
+	if promote {
+		// the argument is the release candidate tag to promote.
+		ver, err := rnr.Promote(ctx, rc)
+		if err != nil {
+			return err
+		}
+		versions = append(versions, ver)
+	} else {
+		versions, err = rnr.Analyze(ctx, rc)
+		if err != nil {
+			return err
+		}
+	}
+	// version files are committed first, so the release commit is shown.
+	if err := rnr.UpdateVersionFiles(ctx, versions); err != nil {
 		return err
 	}
 	cfg.Debugf("will tag %d:", len(versions))
@@ -293,6 +324,38 @@ func run(rawArgs []string) error {
 	return nil
 }
 
+func runPolicyCommand(ctx context.Context, cfg config.Config, rnr *runner.Runner, args []string) error {
+	if len(args) != 2 || args[0] != "test" {
+		return errors.New("usage: tunk policy test <fixtures.yaml>")
+	}
+
+	var rdr io.Reader = os.Stdin
+	if p := args[1]; p != "-" {
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		rdr = f
+	}
+	fixtures, err := runner.ReadPolicyFixtures(rdr)
+	if err != nil {
+		return err
+	}
+
+	rep, err := rnr.TestPolicies(ctx, fixtures)
+	if err != nil {
+		return err
+	}
+	if err := rep.TextSummary(cfg.Term.Stdout); err != nil {
+		return err
+	}
+	if failed := rep.Failed(); failed > 0 {
+		return fmt.Errorf("%d of %d policy fixture(s) failed", failed, len(fixtures))
+	}
+	return nil
+}
+
 func die(err error) {
 	if err != nil {
 		panic(err)
@@ -301,6 +364,8 @@ func die(err error) {
 
 func usage(cfg config.Config, flags *pflag.FlagSet) {
 	cfg.Printf(`%s [rc]
+%s policy test <fixtures.yaml>
+%s semver <command> [args]
 
 A utility for creating Semantic Version-compliant tags.
 
@@ -329,7 +394,13 @@ $ tunk --all --release-scope myscope --release-scope another-scope
 
 # validate against policies, allowed scopes, and allowed types:
 $ tunk --check
-`, os.Args[0], flags.FlagUsages())
+
+# check that policies match a file of example commit messages:
+$ tunk policy test policy-fixtures.yaml
+
+# print the latest of a list of versions:
+$ git tag | tunk semver sort | tail -n 1
+`, os.Args[0], os.Args[0], os.Args[0], flags.FlagUsages())
 }
 
 func readTunkYAML(p string) (*config.Config, error) {
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/blang/semver/v4"
+	"github.com/mattn/go-isatty"
+
+	"github.com/jeffrom/tunk/commit"
+	"github.com/jeffrom/tunk/config"
+)
+
+const semverUsage = `usage: tunk semver bump major|minor|patch <version>
+       tunk semver bump pre <version> [name]
+       tunk semver compare <version> <version>
+       tunk semver sort [<version>...]
+       tunk semver valid <version>
+       tunk semver get major|minor|patch|pre|build <version>
+       tunk semver satisfies <constraint> <version>`
+
+// runSemverCommand reads and edits versions given as arguments, using the
+// configured version scheme and prerelease channels.
+func runSemverCommand(cfg config.Config, args []string) error {
+	if len(args) == 0 {
+		return errors.New(semverUsage)
+	}
+	tag, err := commit.NewTagFromConfig(cfg.ForScope(cfg.Scope))
+	if err != nil {
+		return err
+	}
+	w := cfg.Term.Stdout
+	cmd, args := args[0], args[1:]
+
+	switch cmd {
+	case "bump":
+		if len(args) < 2 || (args[0] != "pre" && len(args) != 2) || len(args) > 3 {
+			return errors.New(semverUsage)
+		}
+		v, err := tag.ParseVersion(args[1])
+		if err != nil {
+			return err
+		}
+		var next semver.Version
+		switch args[0] {
+		case "major":
+			next = tag.BumpVersion(v, commit.ReleaseMajor, time.Now())
+		case "minor":
+			next = tag.BumpVersion(v, commit.ReleaseMinor, time.Now())
+		case "patch":
+			next = tag.BumpVersion(v, commit.ReleasePatch, time.Now())
+		case "pre":
+			name := ""
+			if len(args) == 3 {
+				name = args[2]
+			}
+			next, err = tag.BumpPrerelease(v, name, time.Now())
+			if err != nil {
+				return err
+			}
+		default:
+			return errors.New(semverUsage)
+		}
+		fmt.Fprintln(w, tag.FormatSemver(next))
+
+	case "compare":
+		if len(args) != 2 {
+			return errors.New(semverUsage)
+		}
+		a, err := tag.ParseVersion(args[0])
+		if err != nil {
+			return err
+		}
+		b, err := tag.ParseVersion(args[1])
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, tag.CompareVersions(a, b))
+
+	case "sort":
+		if len(args) == 0 {
+			if f, ok := cfg.Term.Stdin.(*os.File); ok && isatty.IsTerminal(f.Fd()) {
+				return errors.New(semverUsage)
+			}
+			args, err = readLines(cfg.Term.Stdin)
+			if err != nil {
+				return err
+			}
+		}
+		// strings that aren't versions, such as other tags, are left out.
+		var versions []semver.Version
+		orig := make(map[string][]string)
+		for _, arg := range args {
+			v, err := tag.ParseVersion(arg)
+			if err != nil {
+				cfg.Debugf("skipping %q: %v", arg, err)
+				continue
+			}
+			key := v.String()
+			if _, ok := orig[key]; !ok {
+				versions = append(versions, v)
+			}
+			orig[key] = append(orig[key], arg)
+		}
+		tag.SortVersions(versions)
+		for _, v := range versions {
+			for _, arg := range orig[v.String()] {
+				fmt.Fprintln(w, arg)
+			}
+		}
+
+	case "valid":
+		if len(args) != 1 {
+			return errors.New(semverUsage)
+		}
+		_, err := tag.ParseVersion(args[0])
+		return err
+
+	case "get":
+		if len(args) != 2 {
+			return errors.New(semverUsage)
+		}
+		v, err := tag.ParseVersion(args[1])
+		if err != nil {
+			return err
+		}
+		switch args[0] {
+		case "major":
+			fmt.Fprintln(w, v.Major)
+		case "minor":
+			fmt.Fprintln(w, v.Minor)
+		case "patch":
+			fmt.Fprintln(w, v.Patch)
+		case "pre":
+			ver := &commit.Version{Version: v}
+			fmt.Fprintln(w, strings.Join(ver.Pre(), "."))
+		case "build":
+			fmt.Fprintln(w, strings.Join(tag.BuildMetadata(v), "."))
+		default:
+			return errors.New(semverUsage)
+		}
+
+	case "satisfies":
+		if len(args) != 2 {
+			return errors.New(semverUsage)
+		}
+		r, err := semver.ParseRange(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid constraint %q: %w", args[0], err)
+		}
+		v, err := tag.ParseVersion(args[1])
+		if err != nil {
+			return err
+		}
+		if !r(v) {
+			return fmt.Errorf("%s does not satisfy %q", args[1], args[0])
+		}
+
+	default:
+		return errors.New(semverUsage)
+	}
+	return nil
+}
+
+func readLines(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
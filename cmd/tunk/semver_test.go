@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/jeffrom/tunk/config"
+)
+
+func TestSemverCommand(t *testing.T) {
+	tcs := []struct {
+		name       string
+		args       []string
+		stdin      string
+		cfg        *config.Config
+		expect     string
+		shouldFail bool
+	}{
+		{name: "bump", args: strs("bump", "minor", "v1.2.3"), expect: "1.3.0\n"},
+		{name: "bump-pre", args: strs("bump", "pre", "v1.2.3-rc.0"), expect: "1.2.3-rc.1\n"},
+		{name: "bump-pre-name", args: strs("bump", "pre", "v1.2.3", "beta"), expect: "1.2.4-beta.0\n"},
+		{name: "bump-four-part", args: strs("bump", "patch", "v1.2.3.9"), cfg: &config.Config{VersionScheme: "four_part"}, expect: "1.2.4.10\n"},
+		{name: "bump-invalid", args: strs("bump", "huge", "v1.2.3"), shouldFail: true},
+		{name: "compare", args: strs("compare", "v1.2.3-rc.0", "v1.2.3"), expect: "-1\n"},
+		{name: "compare-channels", args: strs("compare", "v1.2.3-beta.0", "v1.2.3-alpha.1"), cfg: &config.Config{PrereleaseChannels: []string{"beta", "alpha"}}, expect: "-1\n"},
+		{
+			name:   "sort",
+			args:   strs("sort", "v1.10.0", "v1.9.0", "latest", "v1.10.0-rc.2", "1.9.0"),
+			expect: "v1.9.0\n1.9.0\nv1.10.0-rc.2\nv1.10.0\n",
+		},
+		{name: "sort-stdin", args: strs("sort"), stdin: "v0.2.0\nv0.10.0\n\nv0.3.0\n", expect: "v0.2.0\nv0.3.0\nv0.10.0\n"},
+		{name: "valid", args: strs("valid", "cool/v1.2.3")},
+		{name: "valid-four-part", args: strs("valid", "v1.2.3.4"), shouldFail: true},
+		{name: "valid-pre", args: strs("valid", "v1.2.3-rc"), shouldFail: true},
+		{name: "get", args: strs("get", "minor", "v1.2.3"), expect: "2\n"},
+		{name: "get-pre", args: strs("get", "pre", "v1.2.3-rc.4+ci.7"), expect: "rc.4\n"},
+		{name: "get-build", args: strs("get", "build", "v1.2.3-rc.4+ci.7"), expect: "ci.7\n"},
+		{name: "satisfies", args: strs("satisfies", ">=1.2.0 <2.0.0", "v1.3.0")},
+		{name: "satisfies-fail", args: strs("satisfies", ">=2.0.0", "v1.3.0"), shouldFail: true},
+		{name: "unknown", args: strs("frobnicate"), shouldFail: true},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			stdout := &bytes.Buffer{}
+			cfg := config.NewWithTerminalIO(tc.cfg, &config.TerminalIO{
+				Stdin:  strings.NewReader(tc.stdin),
+				Stdout: stdout,
+				Stderr: &bytes.Buffer{},
+			})
+			err := runSemverCommand(cfg, tc.args)
+			if tc.shouldFail {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := stdout.String(); got != tc.expect {
+				t.Errorf("expected output %q, got %q", tc.expect, got)
+			}
+		})
+	}
+}
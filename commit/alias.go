@@ -0,0 +1,69 @@
+package commit
+
+import (
+	"context"
+	"strings"
+
+	"github.com/blang/semver/v4"
+
+	"github.com/jeffrom/tunk/config"
+)
+
+// AliasTags returns the alias tags that should point at the version's release,
+// such as v1 and v1.4 for v1.4.2. Prereleases have none, and neither do
+// version lines that already have a later release, such as the major line of
+// a release from a maintenance branch.
+func (a *Analyzer) AliasTags(ctx context.Context, ver *Version) ([]string, error) {
+	if len(a.cfg.AliasTags) == 0 || len(ver.Version.Pre) > 0 {
+		return nil, nil
+	}
+	a, err := a.ForScope(ver.Scope)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := a.readTags(ctx, ver.Scope, "", a.vcs.ReadTags)
+	if err != nil {
+		return nil, err
+	}
+	var released []semver.Version
+	for _, tag := range tags {
+		v, err := a.tag.ExtractSemver(ver.Scope, "", tag)
+		if err != nil || len(v.Pre) > 0 {
+			continue
+		}
+		released = append(released, v)
+	}
+
+	parts := strings.Split(a.tag.scheme.Format(ver.Version), ".")
+	var aliases []string
+	for i, kind := range []string{config.AliasMajor, config.AliasMinor} {
+		if !inStrs(kind, a.cfg.AliasTags) {
+			continue
+		}
+		line := strings.Join(parts[:i+1], ".")
+		if later := a.laterRelease(released, ver.Version, i+1); later != nil {
+			a.cfg.Debugf("not moving %s alias %s: %s is later", kind, line, later)
+			continue
+		}
+		alias, err := a.tag.Alias(ver.Scope, line)
+		if err != nil {
+			return nil, err
+		}
+		aliases = append(aliases, alias)
+	}
+	return aliases, nil
+}
+
+// laterRelease returns a release later than v that shares its first n
+// components, or nil if there isn't one.
+func (a *Analyzer) laterRelease(released []semver.Version, v semver.Version, n int) *semver.Version {
+	for i, r := range released {
+		if r.Major != v.Major || (n > 1 && r.Minor != v.Minor) {
+			continue
+		}
+		if compareVersions(a.tag.scheme, r, v) > 0 {
+			return &released[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package commit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/blang/semver/v4"
+
+	"github.com/jeffrom/tunk/config"
+	"github.com/jeffrom/tunk/vcs"
+)
+
+func TestAnalyzerAliasTags(t *testing.T) {
+	tio, _, _ := mockTermIO(nil)
+	tcs := []struct {
+		name    string
+		cfg     *config.Config
+		tags    []string
+		version string
+		scope   string
+		expect  []string
+	}{
+		{
+			name:    "disabled",
+			cfg:     &config.Config{},
+			tags:    []string{"v1.4.1"},
+			version: "1.4.2",
+		},
+		{
+			name:    "major-minor",
+			cfg:     &config.Config{AliasTags: []string{"major", "minor"}},
+			tags:    []string{"v1.4.1", "v1", "v1.4"},
+			version: "1.4.2",
+			expect:  []string{"v1", "v1.4"},
+		},
+		{
+			name:    "major",
+			cfg:     &config.Config{AliasTags: []string{"major"}},
+			tags:    []string{"v1.4.1"},
+			version: "1.5.0",
+			expect:  []string{"v1"},
+		},
+		{
+			name:    "rc",
+			cfg:     &config.Config{AliasTags: []string{"major", "minor"}},
+			tags:    []string{"v1.4.1"},
+			version: "1.4.2-rc.0",
+		},
+		{
+			name:    "maintenance",
+			cfg:     &config.Config{AliasTags: []string{"major", "minor"}},
+			tags:    []string{"v1.4.1", "v1.5.0", "v1.5.0-rc.9", "v2.0.0"},
+			version: "1.4.2",
+			expect:  []string{"v1.4"},
+		},
+		{
+			name:    "scope",
+			cfg:     &config.Config{AliasTags: []string{"major"}},
+			tags:    []string{"v3.0.0", "cool/v1.4.1"},
+			version: "1.4.2",
+			scope:   "cool",
+			expect:  []string{"cool/v1"},
+		},
+		{
+			name:    "calver",
+			cfg:     &config.Config{AliasTags: []string{"major", "minor"}, VersionScheme: SchemeCalver, CalverFormat: "YY.0M.MICRO"},
+			tags:    []string{"v24.05.0"},
+			version: "24.5.1",
+			expect:  []string{"v24", "v24.05"},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := newTestConfig(tc.cfg, &tio)
+			tag, err := NewTagFromConfig(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			m := vcs.NewMock().SetTags(tc.tags...)
+			a := NewAnalyzer(cfg, m, tag)
+
+			aliases, err := a.AliasTags(context.Background(), &Version{Version: semver.MustParse(tc.version), Scope: tc.scope})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(aliases) != len(tc.expect) {
+				t.Fatalf("expected aliases %q, got %q", tc.expect, aliases)
+			}
+			for i := range aliases {
+				if aliases[i] != tc.expect[i] {
+					t.Errorf("expected aliases %q, got %q", tc.expect, aliases)
+				}
+			}
+
+			latestTag, _, err := a.LatestReleaseTag(context.Background(), tc.scope, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if inStrs(latestTag, tc.expect) {
+				t.Errorf("expected alias tags to be ignored, got latest release %q", latestTag)
+			}
+		})
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"io"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/blang/semver/v4"
 
@@ -32,10 +33,62 @@ func (e NoMatchingPolicyError) Error() string {
 	return fmt.Sprintf("none of the following policies matched: %v", e.Policies)
 }
 
+// MajorApprovalError is returned when policies decide on a major release, but
+// major releases require approval.
+type MajorApprovalError struct {
+	Scope   string
+	Version semver.Version
+	// Tag is the release's tag, as rendered by the scope's tag template.
+	Tag     string
+	Commits []*AnalyzedCommit
+}
+
+func (e MajorApprovalError) Is(other error) bool {
+	_, ok := other.(MajorApprovalError)
+	return ok
+}
+
+func (e MajorApprovalError) Error() string {
+	b := &strings.Builder{}
+	b.WriteString(fmt.Sprintf("major release %s", e.Tag))
+	if e.Scope != "" {
+		b.WriteString(fmt.Sprintf(" (scope: %q)", e.Scope))
+	}
+	// graduating to 1.0.0 isn't decided by commits, so there are none to list.
+	if len(e.Commits) == 0 {
+		b.WriteString(" requires approval, use --allow-major to release it.")
+	} else {
+		b.WriteString(" requires approval, use --major or --allow-major to release it. Breaking changes:")
+	}
+	for _, ac := range e.Commits {
+		b.WriteString(fmt.Sprintf("\n%s %s", ac.Commit.ShortID(), ac.Commit.Subject))
+		for _, annotation := range ac.BreakingChanges() {
+			b.WriteString(fmt.Sprintf("\n  %s: %s", annotation.Name, strings.ReplaceAll(annotation.Body, "\n", "\n  ")))
+		}
+		if len(ac.Rules) > 0 {
+			b.WriteString(fmt.Sprintf("\n  (rules: %s)", strings.Join(ac.Rules, ", ")))
+		}
+	}
+	return b.String()
+}
+
 type Analyzer struct {
 	cfg config.Config
 	vcs vcs.Interface
 	tag *Tag
+
+	// parent is the top-level analyzer of a scope's analyzer.
+	parent *Analyzer
+	scopes map[string]*Analyzer
+	now    func() time.Time
+
+	// detectedScopes are the scopes found by DetectScopes.
+	detectedScopes []string
+	scopesDetected bool
+
+	// policies are the resolved policies of cfg, see Policies.
+	policies         []*config.Policy
+	policiesResolved bool
 }
 
 func NewAnalyzer(cfg config.Config, vcs vcs.Interface, tag *Tag) *Analyzer {
@@ -50,52 +103,109 @@ func NewAnalyzer(cfg config.Config, vcs vcs.Interface, tag *Tag) *Analyzer {
 		cfg: cfg,
 		vcs: vcs,
 		tag: tag,
+		now: time.Now,
 	}
 }
 
 func (a *Analyzer) Analyze(ctx context.Context, rc string) ([]*Version, error) {
 	var versions []*Version
 
-	// TODO in CI, fetch the main branch. locally, don't fetch.
-	mainBranch, err := a.vcs.GetMainBranch(ctx, a.cfg.GetBranches())
+	var scopes []string
+	if a.cfg.Scope == "" {
+		scopes = append(scopes, "")
+	}
+	if a.cfg.All {
+		if !a.cfg.NoDetectScopes {
+			if _, err := a.DetectScopes(ctx); err != nil {
+				return nil, err
+			}
+		}
+		scopes = append(scopes, a.releaseScopes()...)
+	} else if a.cfg.Scope != "" {
+		scopes = append(scopes, a.cfg.Scope)
+	}
+	// scopes in a version group are always released together.
+	for _, scope := range scopes {
+		for _, member := range a.cfg.VersionGroup(scope) {
+			if !inStrs(member, scopes) {
+				scopes = append(scopes, member)
+			}
+		}
+	}
+
+	line, err := a.maintenanceLine(ctx)
 	if err != nil {
 		return nil, err
 	}
-	a.cfg.Debugf("main branch is: %q", mainBranch)
+	if line != nil {
+		a.cfg.Debugf("maintenance branch %q releases the %s line", line.Branch, line)
+	}
 
-	if !a.cfg.IgnorePolicies {
-		if err := a.checkPolicies(ctx, mainBranch); err != nil {
-			return nil, err
-		}
+	// scopes are released after the scopes they depend on.
+	scopes, err = a.cfg.ScopeOrder(scopes)
+	if err != nil {
+		return nil, err
 	}
+	released := make(map[string]*Version)
+	var groupVers []*Version
 
-	if a.cfg.Scope == "" {
-		rootVersion, err := a.AnalyzeScope(ctx, "", rc)
+	checked := make(map[string]bool)
+	for _, scope := range scopes {
+		sa, err := a.ForScope(scope)
 		if err != nil {
 			return nil, err
 		}
-		if rootVersion != nil {
-			versions = append(versions, rootVersion)
-		}
-	}
 
-	if a.cfg.All {
-		for _, scope := range a.cfg.ReleaseScopes {
-			ver, err := a.AnalyzeScope(ctx, scope, rc)
+		// scopes with their own branches are checked against them.
+		branchKey := strings.Join(sa.cfg.GetBranches(), "\x00")
+		if line == nil && !checked[branchKey] {
+			// TODO in CI, fetch the main branch. locally, don't fetch.
+			mainBranch, err := sa.vcs.GetMainBranch(ctx, sa.cfg.GetBranches())
 			if err != nil {
 				return nil, err
 			}
-			if ver != nil {
-				versions = append(versions, ver)
+			sa.cfg.Debugf("main branch is: %q", mainBranch)
+
+			if !sa.cfg.IgnorePolicies {
+				if err := sa.checkPolicies(ctx, mainBranch); err != nil {
+					return nil, err
+				}
 			}
+			checked[branchKey] = true
 		}
-	} else if a.cfg.Scope != "" {
-		ver, err := a.AnalyzeScope(ctx, a.cfg.Scope, rc)
+
+		var deps []*Version
+		for _, dep := range a.cfg.Scopes[scope].DependsOn {
+			if depVer, ok := released[dep]; ok {
+				deps = append(deps, depVer)
+			}
+		}
+		ver, err := sa.analyzeScope(ctx, scope, rc, line, deps)
 		if err != nil {
 			return nil, err
 		}
+
+		// members of a version group are next to each other, and are
+		// released once the last one is analyzed.
+		if members := a.cfg.VersionGroup(scope); members != nil {
+			groupVers = append(groupVers, ver)
+			if len(groupVers) < len(members) {
+				continue
+			}
+			vers, err := a.alignGroup(ctx, members, groupVers, rc, line)
+			if err != nil {
+				return nil, err
+			}
+			groupVers = nil
+			for _, ver := range vers {
+				versions = append(versions, ver)
+				released[ver.Scope] = ver
+			}
+			continue
+		}
 		if ver != nil {
 			versions = append(versions, ver)
+			released[scope] = ver
 		}
 	}
 
@@ -105,26 +215,151 @@ func (a *Analyzer) Analyze(ctx context.Context, rc string) ([]*Version, error) {
 	return versions, nil
 }
 
+// ForScope returns an analyzer that uses the scope's effective
+// configuration. If the scope has no configuration of its own, the analyzer
+// itself is returned.
+func (a *Analyzer) ForScope(scope string) (*Analyzer, error) {
+	root := a.root()
+	if !root.cfg.HasScopeConfig(scope) {
+		return root, nil
+	}
+	if sa, ok := root.scopes[scope]; ok {
+		return sa, nil
+	}
+
+	cfg := root.cfg.ForScope(scope)
+	tag, err := NewTagFromConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("scope %q: %w", scope, err)
+	}
+	sa := &Analyzer{cfg: cfg, vcs: root.vcs, tag: tag, parent: root, now: root.now}
+	if root.scopes == nil {
+		root.scopes = make(map[string]*Analyzer)
+	}
+	root.scopes[scope] = sa
+	return sa, nil
+}
+
+// Policies returns the policies of the analyzer's config. They're resolved
+// once, so matching commits doesn't compile them again.
+func (a *Analyzer) Policies() []*config.Policy {
+	if !a.policiesResolved {
+		a.policies = a.cfg.GetPolicies()
+		a.policiesResolved = true
+	}
+	return a.policies
+}
+
+func (a *Analyzer) root() *Analyzer {
+	if a.parent != nil {
+		return a.parent
+	}
+	return a
+}
+
 func (a *Analyzer) LatestRelease(ctx context.Context, scope, rc string) (semver.Version, error) {
-	glob, err := a.tag.Glob(scope, rc)
+	_, latest, err := a.LatestReleaseTag(ctx, scope, rc)
+	return latest, err
+}
+
+// LatestReleaseTag returns the latest release tag and its version.
+func (a *Analyzer) LatestReleaseTag(ctx context.Context, scope, rc string) (string, semver.Version, error) {
+	a, err := a.ForScope(scope)
 	if err != nil {
-		return semver.Version{}, err
+		return "", semver.Version{}, err
 	}
-	// fmt.Printf("da glob: %q\n", glob)
-	tags, err := a.vcs.ReadTags(ctx, glob)
+	line, err := a.maintenanceLine(ctx)
 	if err != nil {
-		return semver.Version{}, err
+		return "", semver.Version{}, err
+	}
+	if line != nil {
+		return a.latestLineReleaseTag(ctx, scope, rc, line)
+	}
+	tags, err := a.readTags(ctx, scope, rc, a.vcs.ReadTags)
+	if err != nil {
+		return "", semver.Version{}, err
 	}
 	// fmt.Println("the tags:", tags)
-	latest, err := a.tag.SemverLatest(tags, scope, rc)
+	tag, latest, err := a.tag.Latest(tags, scope, rc)
+	if err != nil {
+		return "", semver.Version{}, err
+	}
+	return tag, latest, nil
+}
+
+// latestLineReleaseTag returns the latest release of the maintenance line
+// that is reachable from the current commit.
+func (a *Analyzer) latestLineReleaseTag(ctx context.Context, scope, rc string, line *MaintenanceLine) (string, semver.Version, error) {
+	tags, err := a.readTags(ctx, scope, rc, func(ctx context.Context, glob string) ([]string, error) {
+		return a.vcs.ReadMergedTags(ctx, "HEAD", glob)
+	})
+	if err != nil {
+		return "", semver.Version{}, err
+	}
+	var lineTags []string
+	for _, tag := range tags {
+		if v, err := a.tag.ExtractSemver(scope, rc, tag); err == nil && line.Contains(v) {
+			lineTags = append(lineTags, tag)
+		}
+	}
+	tag, latest, err := a.tag.Latest(lineTags, scope, rc)
+	if err != nil {
+		if errors.Is(err, ErrNoTags) {
+			return "", semver.Version{}, fmt.Errorf("no releases of the %s line are reachable from maintenance branch %q: %w", line, line.Branch, err)
+		}
+		return "", semver.Version{}, err
+	}
+	return tag, latest, nil
+}
+
+// readTags reads the scope's tags using read, in the formats of the current and
+// legacy tag templates.
+func (a *Analyzer) readTags(ctx context.Context, scope, rc string, read func(ctx context.Context, glob string) ([]string, error)) ([]string, error) {
+	globs, err := a.tag.Globs(scope, rc)
+	if err != nil {
+		return nil, err
+	}
+	var res []string
+	for _, glob := range globs {
+		tags, err := read(ctx, glob)
+		if err != nil {
+			return nil, err
+		}
+		for _, tag := range tags {
+			if !inStrs(tag, res) {
+				res = append(res, tag)
+			}
+		}
+	}
+	return res, nil
+}
+
+// releaseTag returns the tag of a release. Tags with build metadata, or in a
+// legacy format, can't be rendered from the version alone, so they're looked
+// up instead.
+func (a *Analyzer) releaseTag(ctx context.Context, scope string, v semver.Version) (string, error) {
+	if len(v.Build) == 0 && !a.tag.HasLegacy() {
+		return a.tag.ExecuteString(TagData{Version: &Version{Version: v, Scope: scope}})
+	}
+	tags, err := a.readTags(ctx, scope, "", a.vcs.ReadTags)
 	if err != nil {
-		return semver.Version{}, err
+		return "", err
+	}
+	for _, tag := range tags {
+		parsed, err := a.tag.ExtractSemver(scope, "", tag)
+		if err == nil && parsed.String() == v.String() {
+			return tag, nil
+		}
 	}
-	return latest, nil
+	return "", fmt.Errorf("commit: no tag found for version %s", v)
 }
 
 func (a *Analyzer) ReadCommitsSince(ctx context.Context, scope string, latest semver.Version) ([]*model.Commit, error) {
-	q, err := a.tag.ExecuteString(TagData{Version: &Version{Version: latest, Scope: scope}})
+	a, err := a.ForScope(scope)
+	if err != nil {
+		return nil, err
+	}
+	q, err := a.releaseTag(ctx, scope, latest)
 	if err != nil {
 		return nil, err
 	}
@@ -133,11 +368,153 @@ func (a *Analyzer) ReadCommitsSince(ctx context.Context, scope string, latest se
 	return a.vcs.ReadCommits(ctx, logQuery)
 }
 
+// Promote returns the final release of a release candidate, on the release
+// candidate's commit. If rcTag is empty, the latest release candidate is
+// promoted. It fails if the final release, or a later one, already exists.
+func (a *Analyzer) Promote(ctx context.Context, scope, rcTag string) (*Version, error) {
+	a, err := a.ForScope(scope)
+	if err != nil {
+		return nil, err
+	}
+	if rcTag == "" {
+		rcTag, err = a.latestRCTag(ctx, scope)
+		if err != nil {
+			return nil, err
+		}
+	}
+	rcCommit, err := a.vcs.ResolveRef(ctx, rcTag)
+	if err != nil {
+		return nil, err
+	}
+
+	rcVer, err := a.tag.ExtractSemver(scope, "", rcTag)
+	if err != nil {
+		return nil, fmt.Errorf("%q is not a release tag: %w", rcTag, err)
+	}
+	if len(rcVer.Pre) == 0 {
+		return nil, fmt.Errorf("%q is not a release candidate", rcTag)
+	}
+	final := a.tag.Release(rcVer)
+
+	logQuery := rcCommit
+	latestTag, latest, err := a.LatestReleaseTag(ctx, scope, "")
+	if err != nil && !errors.Is(err, ErrNoTags) {
+		return nil, err
+	}
+	if err == nil {
+		if c := compareVersions(a.tag.scheme, latest, final); c == 0 {
+			return nil, fmt.Errorf("%s has already been released as %s", rcTag, latestTag)
+		} else if c > 0 {
+			return nil, fmt.Errorf("%s is older than the latest release %s", rcTag, latestTag)
+		}
+		logQuery = fmt.Sprintf("%s..%s", latestTag, rcCommit)
+	}
+	a.cfg.Debugf("promote %s (%s), log: %q", rcTag, rcCommit, logQuery)
+
+	commits, err := a.vcs.ReadCommits(ctx, logQuery)
+	if err != nil {
+		return nil, err
+	}
+	var acs []*AnalyzedCommit
+	for _, commit := range commits {
+		ac, err := a.MatchScope(commit)
+		if err != nil {
+			if !errors.Is(err, NoMatchingPolicyError{}) {
+				return nil, err
+			}
+			ac = &AnalyzedCommit{Commit: commit}
+		}
+		if ac.Ignored || !ac.isScoped(scope, a.releaseScopes()) {
+			continue
+		}
+		acs = append(acs, ac)
+	}
+
+	return &Version{
+		Commit:     rcCommit,
+		Version:    final,
+		Scope:      scope,
+		AllCommits: acs,
+	}, nil
+}
+
+// latestRCTag returns the latest release candidate tag of any name.
+func (a *Analyzer) latestRCTag(ctx context.Context, scope string) (string, error) {
+	tags, err := a.readTags(ctx, scope, "", a.vcs.ReadTags)
+	if err != nil {
+		return "", err
+	}
+	var latestTag string
+	var latest semver.Version
+	for _, tag := range tags {
+		v, err := a.tag.ExtractSemver(scope, "", tag)
+		if err != nil || !validTunkPre(v.Pre) {
+			continue
+		}
+		vers := tunkVersions{versions: []semver.Version{latest, v}, channels: a.tag.channels, scheme: a.tag.scheme}
+		if latestTag == "" || vers.Less(0, 1) {
+			latestTag, latest = tag, v
+		}
+	}
+	if latestTag == "" {
+		return "", errors.New("commit: no release candidate tags found")
+	}
+	return latestTag, nil
+}
+
+// AnalyzeScope determines the next version for the scope, using the scope's
+// effective configuration.
 func (a *Analyzer) AnalyzeScope(ctx context.Context, scope, rc string) (*Version, error) {
+	line, err := a.maintenanceLine(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return a.analyzeScope(ctx, scope, rc, line, nil)
+}
+
+// analyzeScope determines the next version for the scope. Releases of the
+// scope's dependencies, deps, release the scope at least as a patch. On
+// maintenance branches, it refuses versions outside of the branch's line.
+func (a *Analyzer) analyzeScope(ctx context.Context, scope, rc string, line *MaintenanceLine, deps []*Version) (*Version, error) {
+	ver, err := a.nextVersion(ctx, scope, rc, line, deps)
+	if err != nil || ver == nil {
+		return ver, err
+	}
+	if line == nil {
+		return ver, nil
+	}
+	if err := checkMaintenanceLine(line, ver.Version); err != nil {
+		return nil, err
+	}
+	// the tag may have been released from another branch.
+	sa, err := a.ForScope(scope)
+	if err != nil {
+		return nil, err
+	}
+	tag, err := sa.tag.ExecuteString(TagData{Version: ver})
+	if err != nil {
+		return nil, err
+	}
+	if tags, err := sa.vcs.ReadTags(ctx, tag); err != nil {
+		return nil, err
+	} else if len(tags) > 0 {
+		return nil, fmt.Errorf("tag %s already exists, but isn't reachable from maintenance branch %q", tag, line.Branch)
+	}
+	return ver, nil
+}
+
+func (a *Analyzer) nextVersion(ctx context.Context, scope, rc string, line *MaintenanceLine, deps []*Version) (*Version, error) {
+	a, err := a.ForScope(scope)
+	if err != nil {
+		return nil, err
+	}
+	if rc != "" && len(a.cfg.PrereleaseChannels) > 0 && a.cfg.PrereleaseChannel(rc) < 0 {
+		return nil, fmt.Errorf("prerelease %q is not one of the prerelease channels: %s", rc, strings.Join(a.cfg.PrereleaseChannels, ", "))
+	}
 	latest, err := a.LatestRelease(ctx, scope, "")
 	if err != nil {
-		if errors.Is(err, ErrNoTags) {
-			initialTag, err := a.tag.ExecuteString(TagData{Version: &Version{Version: semver.Version{Minor: 1}}})
+		if errors.Is(err, ErrNoTags) && line == nil {
+			initialTag, err := a.tag.ExecuteString(TagData{Version: &Version{Version: a.tag.scheme.Initial(a.now()), Scope: scope}})
 			if err != nil {
 				return nil, err
 			}
@@ -154,27 +531,35 @@ func (a *Analyzer) AnalyzeScope(ctx context.Context, scope, rc string) (*Version
 	if err != nil {
 		return nil, err
 	}
-	ver, err := a.processCommits(latest, commits, scope, a.cfg.ReleaseScopes)
+	// scopes in a version group are released from the group's latest version.
+	latest, err = a.groupLatest(ctx, scope, latest)
 	if err != nil {
 		return nil, err
 	}
-
-	if ver != nil && rc != "" {
-		tagQuery, err := a.tag.GlobVersion(scope, rc, ver.Version)
-		if err != nil {
-			return nil, err
+	ver, err := a.processCommits(latest, commits, scope, a.releaseScopes())
+	if err != nil {
+		return nil, err
+	}
+	if len(deps) > 0 {
+		if ver == nil {
+			ver = &Version{
+				Commit:  latestCommit(deps),
+				Version: a.bumpVersion(latest, ReleasePatch),
+				Scope:   scope,
+			}
 		}
-		// fmt.Printf("glob tag query: %q\n", tagQuery)
-		tags, err := a.vcs.ReadTags(ctx, tagQuery)
-		if err != nil && !errors.Is(err, ErrNoTags) {
-			return nil, err
+		for _, dep := range deps {
+			a.cfg.Debugf("dependency %s released %s (scope: %q)", dep.Scope, dep, scope)
 		}
-		pre, err := a.buildLatestRCTag(scope, rc, tags)
+		ver.Dependencies = deps
+	}
+
+	if ver != nil && rc != "" {
+		pre, err := a.prerelease(ctx, scope, rc, ver.Version)
 		if err != nil {
 			return nil, err
 		}
 		ver.Version.Pre = pre
-		// fmt.Printf("should be %q, got %q\n", a.buildLatestRCTag(scope, rc, tags), pre)
 	}
 
 	if a.cfg.OverridesSet() {
@@ -183,33 +568,83 @@ func (a *Analyzer) AnalyzeScope(ctx context.Context, scope, rc string) (*Version
 		}
 
 		// handle overrides
-		if a.cfg.Major {
-			nextVer := latest
-			nextVer.Major++
-			nextVer.Minor = 0
-			nextVer.Patch = 0
+		if _, ok := a.tag.scheme.(SemverScheme); !ok {
+			if a.cfg.Graduate {
+				return nil, fmt.Errorf("cannot graduate to 1.0.0, the %s version scheme has no 0.x versions", a.tag.scheme.Name())
+			}
+			relType := ReleasePatch
+			if a.cfg.Major {
+				relType = ReleaseMajor
+			} else if a.cfg.Minor {
+				relType = ReleaseMinor
+			}
+			nextVer := a.tag.scheme.Bump(latest, relType, a.now())
+			nextVer.Pre = ver.Version.Pre
+			if a.cfg.Major {
+				if err := a.checkForcedMajor(latest, nextVer, scope); err != nil {
+					return nil, err
+				}
+			}
 			ver.Version = nextVer
 			return ver, nil
 		}
-		if a.cfg.Minor {
-			nextVer := latest
+		var nextVer semver.Version
+		switch {
+		case a.cfg.Graduate:
+			if latest.Major != 0 {
+				return nil, fmt.Errorf("cannot graduate to 1.0.0, latest version %s is not 0.x", latest)
+			}
+			nextVer = semver.Version{Major: 1}
+			if err := a.checkForcedMajor(latest, nextVer, scope); err != nil {
+				return nil, err
+			}
+		case a.cfg.Major:
+			nextVer = latest
+			nextVer.Major++
+			nextVer.Minor = 0
+			nextVer.Patch = 0
+		case a.cfg.Minor:
+			nextVer = latest
 			nextVer.Minor++
 			nextVer.Patch = 0
-			ver.Version = nextVer
-			return ver, nil
-		}
-		if a.cfg.Patch {
-			nextVer := latest
-			nextVer.Pre = ver.Version.Pre
+		case a.cfg.Patch:
+			nextVer = latest
 			nextVer.Patch++
-			ver.Version = nextVer
-			return ver, nil
 		}
+		// the prerelease number is counted from the overridden version's
+		// release candidates.
+		nextVer.Pre = nil
+		if rc != "" {
+			pre, err := a.prerelease(ctx, scope, rc, nextVer)
+			if err != nil {
+				return nil, err
+			}
+			nextVer.Pre = pre
+		}
+		ver.Version = nextVer
+		return ver, nil
 	}
 
 	return ver, nil
 }
 
+// prerelease returns the next prerelease of v on the rc channel.
+func (a *Analyzer) prerelease(ctx context.Context, scope, rc string, v semver.Version) ([]semver.PRVersion, error) {
+	if err := a.checkChannel(ctx, scope, rc, v); err != nil {
+		return nil, err
+	}
+	tagQuery, err := a.tag.GlobVersion(scope, rc, v)
+	if err != nil {
+		return nil, err
+	}
+	// fmt.Printf("glob tag query: %q\n", tagQuery)
+	tags, err := a.vcs.ReadTags(ctx, tagQuery)
+	if err != nil && !errors.Is(err, ErrNoTags) {
+		return nil, err
+	}
+	return a.buildLatestRCTag(scope, rc, tags)
+}
+
 func (a *Analyzer) checkPolicies(ctx context.Context, mainBranch string) error {
 	currCommit, err := a.vcs.CurrentCommit(ctx)
 	if err != nil {
@@ -234,9 +669,11 @@ func (a *Analyzer) processCommits(latest semver.Version, commits []*model.Commit
 	var acs []*AnalyzedCommit
 	var maxCommit *AnalyzedCommit
 	var latestCommit *AnalyzedCommit
+	var pinned *semver.Version
+	var pinCommit *AnalyzedCommit
 	for _, commit := range commits {
 		a.cfg.Debugf("%s (%s) -> %s", commit.ID[:8], commit.Author, commit.Subject)
-		ac, err := a.processCommit(commit, a.cfg.GetPolicies())
+		ac, err := a.MatchScope(commit)
 		if err != nil {
 			if errors.Is(err, NoMatchingPolicyError{}) && a.cfg.OverridesSet() {
 				ac = &AnalyzedCommit{Commit: commit}
@@ -245,6 +682,20 @@ func (a *Analyzer) processCommits(latest semver.Version, commits []*model.Commit
 			}
 		}
 
+		if ac.Ignored {
+			a.cfg.Debugf("skipping ignored commit %s", commit.ShortID())
+			continue
+		}
+
+		// the newest Release-As trailer wins.
+		pin, err := readReleaseAs(ac, scope, allScopes)
+		if err != nil {
+			return nil, err
+		}
+		if pin != nil && (pinCommit == nil || ac.Commit.CommitterDate.After(pinCommit.Commit.CommitterDate)) {
+			pinned, pinCommit = pin, ac
+		}
+
 		// fmt.Println("sup", ac.scope, scope, ac.isScoped(scope, allScopes), allScopes)
 		if !ac.isScoped(scope, allScopes) {
 			a.cfg.Debugf("skipping out of scope commit %s (scope: %q, commit scope: %q)", commit.ShortID(), scope, ac.Scope)
@@ -263,6 +714,26 @@ func (a *Analyzer) processCommits(latest semver.Version, commits []*model.Commit
 		acs = append(acs, ac)
 	}
 
+	if pinned != nil {
+		if err := a.validateReleaseAs(latest, *pinned, scope); err != nil {
+			return nil, fmt.Errorf("%s: %w", pinCommit.Commit.ShortID(), err)
+		}
+		if latestCommit == nil || pinCommit.Commit.CommitterDate.After(latestCommit.Commit.CommitterDate) {
+			latestCommit = pinCommit
+		}
+		a.cfg.Debugf("%s: Release-As %s (scope: %q)", pinCommit.Commit.ShortID(), pinned, scope)
+		pinnedVer := *pinned
+		if s, ok := a.tag.scheme.(FourPartScheme); ok {
+			pinnedVer = s.withBuild(pinnedVer, latest)
+		}
+		return &Version{
+			Commit:     latestCommit.Commit.ID,
+			Version:    pinnedVer,
+			Scope:      scope,
+			AllCommits: acs,
+		}, nil
+	}
+
 	if len(acs) == 0 {
 		return nil, nil
 	}
@@ -270,7 +741,10 @@ func (a *Analyzer) processCommits(latest semver.Version, commits []*model.Commit
 	a.cfg.Debugf("analyzed: max: %s %s(%q) latest: %s\n", maxCommit.Commit.ShortID(), maxCommit.ReleaseType, maxCommit.Scope, latestCommit.Commit.ShortID())
 	if maxCommit.ReleaseType >= ReleasePatch {
 		a.cfg.Debugf("%s: will bump %s version (scope: %q)", latestCommit.Commit.ShortID(), maxCommit.ReleaseType, scope)
-		nextVersion := bumpVersion(latest, maxCommit.ReleaseType)
+		nextVersion := a.bumpVersion(latest, maxCommit.ReleaseType)
+		if err := a.checkMajorApproval(latest, nextVersion, scope, acs); err != nil {
+			return nil, err
+		}
 
 		v := &Version{
 			Commit:     latestCommit.Commit.ID,
@@ -288,7 +762,7 @@ func (a *Analyzer) processCommits(latest semver.Version, commits []*model.Commit
 		}
 		return &Version{
 			Commit:     latestCommit.Commit.ID,
-			Version:    bumpVersion(latest, relType),
+			Version:    a.tag.scheme.Bump(latest, relType, a.now()),
 			Scope:      scope,
 			AllCommits: acs,
 		}, nil
@@ -296,11 +770,226 @@ func (a *Analyzer) processCommits(latest semver.Version, commits []*model.Commit
 	return nil, nil
 }
 
+// ReleaseAsTrailer is the commit trailer that pins the next version, for
+// example "Release-As: 2.0.0". To pin the version of a scope, prefix the
+// version with the scope name and "@", for example "Release-As: sdk@2.0.0".
+const ReleaseAsTrailer = "Release-As"
+
+// ReleaseCommitTrailer marks the release commits created for version files,
+// for example "Release-Commit: v1.2.3". Release commits are ignored, so they
+// don't cause another release.
+const ReleaseCommitTrailer = "Release-Commit"
+
+// readReleaseAs returns the version the commit pins for scope, if any.
+// Versions without a scope apply to the commit's own scope.
+func readReleaseAs(ac *AnalyzedCommit, scope string, allScopes []string) (*semver.Version, error) {
+	for _, val := range ac.Commit.TrailerValues(ReleaseAsTrailer) {
+		verStr := val
+		if i := strings.LastIndex(val, "@"); i >= 0 {
+			if val[:i] != scope {
+				continue
+			}
+			verStr = val[i+1:]
+		} else if !ac.isScoped(scope, allScopes) {
+			continue
+		}
+
+		v, err := semver.ParseTolerant(verStr)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid %s version %q: %w", ac.Commit.ShortID(), ReleaseAsTrailer, val, err)
+		}
+		return &v, nil
+	}
+	return nil, nil
+}
+
+// validateReleaseAs checks that a pinned version is greater than the latest
+// release, and survives being rendered as a tag and read back.
+func (a *Analyzer) validateReleaseAs(latest, pinned semver.Version, scope string) error {
+	if len(pinned.Pre) > 0 || len(pinned.Build) > 0 {
+		return fmt.Errorf("%s version %s must not have a prerelease or build metadata", ReleaseAsTrailer, pinned)
+	}
+	if !pinned.GT(latest) {
+		return fmt.Errorf("%s version %s must be greater than the latest release %s", ReleaseAsTrailer, pinned, latest)
+	}
+	tag, err := a.tag.ExecuteString(TagData{Version: &Version{Version: pinned, Scope: scope}})
+	if err != nil {
+		return err
+	}
+	parsed, err := a.tag.ExtractSemver(scope, "", tag)
+	if err != nil || !parsed.EQ(pinned) {
+		return fmt.Errorf("%s version %s can't be represented by the tag template (rendered %q)", ReleaseAsTrailer, pinned, tag)
+	}
+	return nil
+}
+
+func (a *Analyzer) checkMajorApproval(latest, next semver.Version, scope string, acs []*AnalyzedCommit) error {
+	if !a.cfg.RequireMajorApproval || next.Major <= latest.Major || a.cfg.AllowMajor || a.cfg.OverridesSet() {
+		return nil
+	}
+	if _, ok := a.tag.scheme.(*CalverScheme); ok {
+		return nil
+	}
+	var majors []*AnalyzedCommit
+	for _, ac := range acs {
+		if ac.ReleaseType == ReleaseMajor {
+			majors = append(majors, ac)
+		}
+	}
+	tag, err := a.tag.ExecuteString(TagData{Version: &Version{Version: next, Scope: scope}})
+	if err != nil {
+		return err
+	}
+	return MajorApprovalError{Scope: scope, Version: next, Tag: tag, Commits: majors}
+}
+
+// checkForcedMajor checks approval of a major release forced by flags rather
+// than decided by commits, which needs --allow-major when major releases
+// require approval. Calver major versions follow the date, so they're exempt.
+func (a *Analyzer) checkForcedMajor(latest, next semver.Version, scope string) error {
+	if !a.cfg.RequireMajorApproval || a.cfg.AllowMajor || compareVersions(a.tag.scheme, next, latest) <= 0 {
+		return nil
+	}
+	if _, ok := a.tag.scheme.(*CalverScheme); ok {
+		return nil
+	}
+	tag, err := a.tag.ExecuteString(TagData{Version: &Version{Version: next, Scope: scope}})
+	if err != nil {
+		return err
+	}
+	return MajorApprovalError{Scope: scope, Version: next, Tag: tag}
+}
+
 func (a *Analyzer) Match(commit *model.Commit, policies []*config.Policy) (*AnalyzedCommit, error) {
 	return a.processCommit(commit, policies)
 }
 
+// MatchScope matches the commit against the policies of the scope it belongs
+// to. The commit's scope is read using the top-level policies, falling back to
+// the policies of each configured scope.
+func (a *Analyzer) MatchScope(commit *model.Commit) (*AnalyzedCommit, error) {
+	a = a.root()
+	ac, err := a.processCommit(commit, a.Policies())
+	if err != nil && !errors.Is(err, NoMatchingPolicyError{}) {
+		return nil, err
+	}
+	if err == nil && !a.cfg.HasScopeConfig(ac.Scope) {
+		return ac, nil
+	}
+	if err == nil {
+		sa, serr := a.ForScope(ac.Scope)
+		if serr != nil {
+			return nil, serr
+		}
+		sac, serr := sa.processCommit(commit, sa.Policies())
+		if serr != nil {
+			return nil, fmt.Errorf("scope %q: %w", ac.Scope, serr)
+		}
+		// the scope's policies may not read scopes.
+		if sac.Scope == "" {
+			sac.Scope, sac.Scopes = ac.Scope, ac.Scopes
+		}
+		return sac, nil
+	}
+
+	names := make([]string, 0, len(a.cfg.Scopes))
+	for name := range a.cfg.Scopes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		sa, serr := a.ForScope(name)
+		if serr != nil {
+			return nil, serr
+		}
+		if sac, serr := sa.processCommit(commit, sa.Policies()); serr == nil && sac.HasScope(name) {
+			return sac, nil
+		}
+	}
+	return nil, err
+}
+
 func (a *Analyzer) processCommit(commit *model.Commit, policies []*config.Policy) (*AnalyzedCommit, error) {
+	ac, err := a.matchPolicies(commit, policies)
+	if err != nil && !errors.Is(err, NoMatchingPolicyError{}) {
+		return nil, err
+	}
+	if a.isIgnored(commit, ac) {
+		if ac == nil {
+			ac = &AnalyzedCommit{Commit: commit}
+		}
+		ac.Ignored = true
+		ac.ReleaseType = ReleaseSkip
+		return ac, nil
+	}
+	if len(a.cfg.Rules) == 0 {
+		return ac, err
+	}
+
+	matchErr := err
+	if ac == nil {
+		ac = &AnalyzedCommit{Commit: commit}
+	}
+	if a.applyRules(ac) {
+		return ac, nil
+	}
+	if matchErr != nil {
+		return nil, matchErr
+	}
+	return ac, nil
+}
+
+// isIgnored returns true if the commit matches any of the ignore conditions.
+// ac is the result of matching policies, and may be nil.
+func (a *Analyzer) isIgnored(commit *model.Commit, ac *AnalyzedCommit) bool {
+	var scopes []string
+	if ac != nil {
+		scopes = ac.ScopeNames()
+	}
+	if len(commit.TrailerValues(ReleaseCommitTrailer)) > 0 {
+		a.cfg.Debugf("%s: ignored release commit", commit.ShortID())
+		return true
+	}
+	for i := range a.cfg.Ignore {
+		if ok, _ := a.cfg.Ignore[i].Match(commit, scopes...); ok {
+			a.cfg.Debugf("%s: ignored by condition #%d", commit.ShortID(), i+1)
+			return true
+		}
+	}
+	return false
+}
+
+// applyRules evaluates the configured rules in order, returning true if any
+// of them matched. The first matching rule with a type sets the release type,
+// and any matching rule with a min_type raises it.
+func (a *Analyzer) applyRules(ac *AnalyzedCommit) bool {
+	typeSet := false
+	for i := range a.cfg.Rules {
+		rule := &a.cfg.Rules[i]
+		label := rule.Label(i)
+		if ok, attr := rule.Match(ac.Commit, ac.ScopeNames()...); !ok {
+			a.cfg.Debugf("%s: rule %s: %s did not match", ac.Commit.ShortID(), label, attr)
+			continue
+		}
+
+		prev := ac.ReleaseType
+		if rule.Type != "" {
+			if typeSet {
+				a.cfg.Debugf("%s: rule %s: matched, but release type was already set by a previous rule", ac.Commit.ShortID(), label)
+				continue
+			}
+			ac.ReleaseType = ReleaseTypeFromString(rule.Type)
+			typeSet = true
+		} else if rt := ReleaseTypeFromString(rule.MinType); rt > ac.ReleaseType {
+			ac.ReleaseType = rt
+		}
+		ac.Rules = append(ac.Rules, label)
+		a.cfg.Debugf("%s: rule %s: matched (%s -> %s)", ac.Commit.ShortID(), label, prev, ac.ReleaseType)
+	}
+	return len(ac.Rules) > 0
+}
+
+func (a *Analyzer) matchPolicies(commit *model.Commit, policies []*config.Policy) (*AnalyzedCommit, error) {
 	for _, pol := range policies {
 		subjectRE := pol.GetSubjectRE()
 		var subjectMatch []string
@@ -328,10 +1017,20 @@ func (a *Analyzer) processCommit(commit *model.Commit, policies []*config.Policy
 					}
 				case "scope":
 					a.cfg.Debugf("%s: policy %q subject scope: %q", commit.ShortID(), pol.Name, group)
-					ac.Scope = strings.Trim(group, "~!@#$%^&*()_+`-=[]\\{}|';:\",./<>?")
+					ac.Scopes = splitScopes(group, a.cfg.ScopeSeparator)
+					if len(ac.Scopes) > 0 {
+						ac.Scope = ac.Scopes[0]
+					}
+				case "breaking":
+					ac.Breaking = group != ""
 				}
 			}
 
+			if !typeMatch && pol.DefaultReleaseType != "" {
+				ac.ReleaseType = ReleaseTypeFromString(pol.DefaultReleaseType)
+				typeMatch = true
+			}
+
 			if ac.Scope != "" && ac.ReleaseType == 0 && pol.FallbackReleaseType != "" {
 				ac.ReleaseType = ReleaseTypeFromString(pol.FallbackReleaseType)
 				typeMatch = true
@@ -342,7 +1041,8 @@ func (a *Analyzer) processCommit(commit *model.Commit, policies []*config.Policy
 				if err != nil {
 					return nil, err
 				}
-				if breaking {
+				if breaking || ac.Breaking {
+					ac.Breaking = true
 					ac.ReleaseType = ReleaseMajor
 				}
 
@@ -368,14 +1068,7 @@ func (a *Analyzer) detectBreakingChange(pol *config.Policy, ac *AnalyzedCommit)
 		}
 		ac.Annotations = annotations
 	}
-	for _, annotation := range ac.Annotations {
-		for _, bcn := range pol.BreakingChangeTypes {
-			if annotation.Name == bcn {
-				return true, nil
-			}
-		}
-	}
-	return false, nil
+	return len(ac.BreakingChanges()) > 0, nil
 }
 
 func (a *Analyzer) getBodyAnnotations(pol *config.Policy, body string) ([]BodyAnnotation, error) {
@@ -429,6 +1122,36 @@ func (a *Analyzer) getBodyAnnotations(pol *config.Policy, body string) ([]BodyAn
 	return annotations, nil
 }
 
+// checkChannel refuses to release a prerelease of v on an earlier channel than
+// one that has already been released.
+func (a *Analyzer) checkChannel(ctx context.Context, scope, rc string, v semver.Version) error {
+	idx := a.cfg.PrereleaseChannel(rc)
+	if idx < 0 {
+		return nil
+	}
+	glob, err := a.tag.GlobVersion(scope, "*", v)
+	if err != nil {
+		return err
+	}
+	tags, err := a.vcs.ReadTags(ctx, glob)
+	if err != nil && !errors.Is(err, ErrNoTags) {
+		return err
+	}
+	for _, tag := range tags {
+		parsed, err := a.tag.ExtractSemver(scope, "", tag)
+		if err != nil || !validTunkPre(parsed.Pre) {
+			continue
+		}
+		if compareVersions(a.tag.scheme, parsed, v) != 0 {
+			continue
+		}
+		if ch := parsed.Pre[0].String(); a.cfg.PrereleaseChannel(ch) > idx {
+			return fmt.Errorf("%s has already been released, so %s can't go back to the %q channel", tag, v, rc)
+		}
+	}
+	return nil
+}
+
 func (a *Analyzer) buildLatestRCTag(scope, rc string, tags []string) ([]semver.PRVersion, error) {
 	var nums []int
 	for _, t := range tags {
@@ -464,8 +1187,24 @@ func (a *Analyzer) buildLatestRCTag(scope, rc string, tags []string) ([]semver.P
 	}, nil
 }
 
+// bumpVersion bumps the version according to policy and the version scheme.
+// When zero_major is set, 0.x semver versions bump the minor version for
+// breaking changes, and the patch version for features.
+func (a *Analyzer) bumpVersion(curr semver.Version, releaseType ReleaseType) semver.Version {
+	if _, ok := a.tag.scheme.(SemverScheme); ok && a.cfg.ZeroMajor && curr.Major == 0 {
+		switch releaseType {
+		case ReleaseMajor:
+			releaseType = ReleaseMinor
+		case ReleaseMinor:
+			releaseType = ReleasePatch
+		}
+	}
+	return a.tag.scheme.Bump(curr, releaseType, a.now())
+}
+
 func bumpVersion(curr semver.Version, releaseType ReleaseType) semver.Version {
 	nextVersion := curr
+	nextVersion.Build = nil
 	switch releaseType {
 	case ReleaseMajor:
 		nextVersion.Major++
@@ -484,13 +1223,25 @@ func bumpVersion(curr semver.Version, releaseType ReleaseType) semver.Version {
 type AnalyzedCommit struct {
 	*model.Commit
 	ReleaseType ReleaseType
-	Scope       string
-	CommitType  string
-	Policy      *config.Policy
+	// Scope is the commit's first scope.
+	Scope string
+	// Scopes are all of the commit's scopes, such as api and cli for
+	// "feat(api,cli): ...".
+	Scopes     []string
+	CommitType string
+	Policy     *config.Policy
 	// Valid, when false, indicates that the commit didn't match any policies,
 	// but there was a fallback.
-	Valid       bool
+	Valid bool
+	// Breaking is true when the commit was marked as a breaking change, either
+	// by a body annotation or by the subject.
+	Breaking    bool
 	Annotations []BodyAnnotation
+	// Rules are the labels of the rules that matched the commit, in order.
+	Rules []string
+	// Ignored is true when the commit matched an ignore condition. Ignored
+	// commits don't affect releases.
+	Ignored bool
 }
 
 type AnalyzedCommits []*AnalyzedCommit
@@ -510,19 +1261,44 @@ func (acs AnalyzedCommits) TextSummary(w io.Writer) error {
 		if ac.Policy != nil {
 			bw.WriteString(fmt.Sprintf("  Policy: %s\n", ac.Policy.Name))
 		}
-		bw.WriteString(fmt.Sprintf("  Release type: %s\n", ac.ReleaseType))
-		if ac.Scope != "" {
-			bw.WriteString(fmt.Sprintf("  Scope: %s\n", ac.Scope))
+		if ac.Ignored {
+			bw.WriteString("  Release type: ignored\n")
+		} else {
+			bw.WriteString(fmt.Sprintf("  Release type: %s\n", ac.ReleaseType))
+		}
+		if scopes := ac.ScopeNames(); len(scopes) > 0 {
+			bw.WriteString(fmt.Sprintf("  Scope: %s\n", strings.Join(scopes, ", ")))
 		}
 		if ac.CommitType != "" {
 			bw.WriteString(fmt.Sprintf("  Commit Type: %s\n", ac.CommitType))
 		}
+		if len(ac.Rules) > 0 {
+			bw.WriteString(fmt.Sprintf("  Rules: %s\n", strings.Join(ac.Rules, ", ")))
+		}
 	}
 	return bw.Flush()
 }
 
+// BreakingChanges returns the commit's breaking change annotations.
+func (ac *AnalyzedCommit) BreakingChanges() []BodyAnnotation {
+	if ac.Policy == nil {
+		return nil
+	}
+	var annotations []BodyAnnotation
+	for _, annotation := range ac.Annotations {
+		for _, bcn := range ac.Policy.BreakingChangeTypes {
+			if annotation.Name == bcn {
+				annotations = append(annotations, annotation)
+				break
+			}
+		}
+	}
+	return annotations
+}
+
 func (ac *AnalyzedCommit) isScoped(scope string, allScopes []string) bool {
-	if ac.Scope == "" {
+	scopes := ac.ScopeNames()
+	if len(scopes) == 0 {
 		for _, other := range allScopes {
 			if scope == other {
 				return false
@@ -531,11 +1307,54 @@ func (ac *AnalyzedCommit) isScoped(scope string, allScopes []string) bool {
 		return true
 	}
 	if len(allScopes) > 0 {
-		return scope == ac.Scope
+		return inStrs(scope, scopes)
 	}
 	return true
 }
 
+// ScopeNames returns the commit's scopes.
+func (ac *AnalyzedCommit) ScopeNames() []string {
+	if len(ac.Scopes) > 0 {
+		return ac.Scopes
+	}
+	if ac.Scope != "" {
+		return []string{ac.Scope}
+	}
+	return nil
+}
+
+// HasScope returns true if the commit belongs to scope. Commits without
+// scopes belong to the main scope, "".
+func (ac *AnalyzedCommit) HasScope(scope string) bool {
+	scopes := ac.ScopeNames()
+	if scope == "" {
+		return len(scopes) == 0
+	}
+	return inStrs(scope, scopes)
+}
+
+// splitScopes reads the scopes from a policy's scope match, which are
+// separated by sep.
+func splitScopes(s, sep string) []string {
+	const cutset = "~!@#$%^&*()_+`-=[]\\{}|';:\",./<>?"
+	s = strings.Trim(s, cutset)
+	if s == "" {
+		return nil
+	}
+	parts := []string{s}
+	if sep != "" {
+		parts = strings.Split(s, sep)
+	}
+	var scopes []string
+	for _, part := range parts {
+		part = strings.Trim(strings.TrimSpace(part), cutset)
+		if part != "" && !inStrs(part, scopes) {
+			scopes = append(scopes, part)
+		}
+	}
+	return scopes
+}
+
 type BodyAnnotation struct {
 	Name string
 	Body string
@@ -3,7 +3,9 @@ package commit
 import (
 	"bytes"
 	"context"
+	"errors"
 	"io"
+	"strings"
 	"testing"
 
 	"github.com/blang/semver/v4"
@@ -261,6 +263,230 @@ func TestAnalyzeMajor(t *testing.T) {
 	}
 }
 
+func TestAnalyzeMajorApproval(t *testing.T) {
+	tio, _, _ := mockTermIO(nil)
+	tcs := []struct {
+		name          string
+		cfg           *config.Config
+		shouldFail    bool
+		expectTag     string
+		expectVersion string
+	}{
+		{
+			name:       "unapproved",
+			cfg:        &config.Config{RequireMajorApproval: true},
+			shouldFail: true,
+			expectTag:  "v1.0.0",
+		},
+		{
+			name:       "unapproved-scope",
+			cfg:        &config.Config{RequireMajorApproval: true, Scope: "cool"},
+			shouldFail: true,
+			expectTag:  "cool/v1.0.0",
+		},
+		{
+			name: "unapproved-scope-template",
+			cfg: &config.Config{
+				RequireMajorApproval: true,
+				Scope:                "cool",
+				Scopes:               map[string]config.ScopeConfig{"cool": {TagTemplate: `cool-{{ semver .Version }}`}},
+			},
+			shouldFail: true,
+			expectTag:  "cool-1.0.0",
+		},
+		{
+			name:          "allow-major",
+			cfg:           &config.Config{RequireMajorApproval: true, AllowMajor: true},
+			expectVersion: "1.0.0",
+		},
+		{
+			name:          "major",
+			cfg:           &config.Config{RequireMajorApproval: true, Major: true},
+			expectVersion: "1.0.0",
+		},
+		{
+			name:          "zero-major",
+			cfg:           &config.Config{RequireMajorApproval: true, ZeroMajor: true},
+			expectVersion: "0.2.0",
+		},
+		{
+			name: "scope-disabled",
+			cfg: &config.Config{
+				RequireMajorApproval: true,
+				Scope:                "cool",
+				Scopes:               map[string]config.ScopeConfig{"cool": {RequireMajorApproval: new(bool)}},
+			},
+			expectVersion: "1.0.0",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := newTestConfig(tc.cfg, &tio)
+			m := vcs.NewMock().SetTags("v0.1.0", "cool/v0.1.0", "cool-0.1.0").SetCommits(commitWithID(conventionalMajorCommit, "12345678"), conventionalMinorCommit)
+			a := NewAnalyzer(cfg, m, nil)
+
+			vers, err := a.Analyze(context.Background(), "")
+			if tc.shouldFail {
+				if !errors.Is(err, MajorApprovalError{}) {
+					t.Fatalf("expected major approval error, got %v", err)
+				}
+				mae := err.(MajorApprovalError)
+				if len(mae.Commits) != 1 || mae.Commits[0].Commit.ID != "12345678" {
+					t.Errorf("expected breaking commit 12345678, got %+v", mae.Commits)
+				}
+				if mae.Tag != tc.expectTag {
+					t.Errorf("expected tag %q, got %q", tc.expectTag, mae.Tag)
+				}
+				if !strings.HasPrefix(err.Error(), "major release "+tc.expectTag+" ") {
+					t.Errorf("expected error to name the tag %q, got %q", tc.expectTag, err)
+				}
+				if !strings.Contains(err.Error(), "BREAKING CHANGE: nice breakin change") {
+					t.Errorf("expected error to contain the breaking change annotation, got %q", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(vers) != 1 {
+				t.Fatalf("expected 1 version, got %d", len(vers))
+			}
+			expectVersion := semver.MustParse(tc.expectVersion)
+			if ver := vers[0]; ver.Version.NE(expectVersion) {
+				t.Errorf("expected version %s, got %s", expectVersion, ver.Version)
+			}
+		})
+	}
+}
+
+func TestAnalyzeZeroMajor(t *testing.T) {
+	tio, _, _ := mockTermIO(nil)
+	tcs := []struct {
+		name          string
+		tags          []string
+		commits       []*model.Commit
+		graduate      bool
+		rc            string
+		approval      bool
+		allowMajor    bool
+		expectVersion string
+		shouldFail    bool
+	}{
+		{
+			name:          "major",
+			tags:          []string{"v0.4.2"},
+			commits:       []*model.Commit{conventionalMajorCommit},
+			expectVersion: "0.5.0",
+		},
+		{
+			name:          "minor",
+			tags:          []string{"v0.4.2"},
+			commits:       []*model.Commit{conventionalMinorCommit},
+			expectVersion: "0.4.3",
+		},
+		{
+			name:          "patch",
+			tags:          []string{"v0.4.2"},
+			commits:       []*model.Commit{conventionalPatchCommit},
+			expectVersion: "0.4.3",
+		},
+		{
+			name:          "stable-major",
+			tags:          []string{"v1.2.3"},
+			commits:       []*model.Commit{conventionalMajorCommit},
+			expectVersion: "2.0.0",
+		},
+		{
+			name:          "stable-minor",
+			tags:          []string{"v1.2.3"},
+			commits:       []*model.Commit{conventionalMinorCommit},
+			expectVersion: "1.3.0",
+		},
+		{
+			name:          "graduate",
+			tags:          []string{"v0.4.2"},
+			commits:       []*model.Commit{conventionalPatchCommit},
+			graduate:      true,
+			expectVersion: "1.0.0",
+		},
+		{
+			name:       "graduate-stable",
+			tags:       []string{"v1.2.3"},
+			commits:    []*model.Commit{conventionalPatchCommit},
+			graduate:   true,
+			shouldFail: true,
+		},
+		{
+			name:          "graduate-rc",
+			tags:          []string{"v0.4.0"},
+			commits:       []*model.Commit{conventionalPatchCommit},
+			graduate:      true,
+			rc:            "rc",
+			expectVersion: "1.0.0-rc.0",
+		},
+		{
+			name:          "graduate-next-rc",
+			tags:          []string{"v0.4.0", "v0.4.1-rc.3", "v1.0.0-rc.0"},
+			commits:       []*model.Commit{conventionalPatchCommit},
+			graduate:      true,
+			rc:            "rc",
+			expectVersion: "1.0.0-rc.1",
+		},
+		{
+			name:       "graduate-unapproved",
+			tags:       []string{"v0.4.2"},
+			commits:    []*model.Commit{conventionalPatchCommit},
+			graduate:   true,
+			approval:   true,
+			shouldFail: true,
+		},
+		{
+			name:          "graduate-allow-major",
+			tags:          []string{"v0.4.2"},
+			commits:       []*model.Commit{conventionalPatchCommit},
+			graduate:      true,
+			approval:      true,
+			allowMajor:    true,
+			expectVersion: "1.0.0",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := newTestConfig(&config.Config{
+				ZeroMajor:            true,
+				Graduate:             tc.graduate,
+				RequireMajorApproval: tc.approval,
+				AllowMajor:           tc.allowMajor,
+			}, &tio)
+			m := vcs.NewMock().SetTags(tc.tags...).SetCommits(tc.commits...)
+			a := NewAnalyzer(cfg, m, nil)
+
+			vers, err := a.Analyze(context.Background(), tc.rc)
+			if tc.shouldFail {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				if tc.approval && !errors.Is(err, MajorApprovalError{}) {
+					t.Fatalf("expected major approval error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(vers) != 1 {
+				t.Fatalf("expected 1 version, got %d", len(vers))
+			}
+			expectVersion := semver.MustParse(tc.expectVersion)
+			if ver := vers[0]; ver.Version.NE(expectVersion) {
+				t.Errorf("expected version %s, got %s", expectVersion, ver.Version)
+			}
+		})
+	}
+}
+
 func TestAnalyzeRC(t *testing.T) {
 	tio, _, _ := mockTermIO(nil)
 
@@ -332,25 +558,24 @@ func TestAnalyzeRC(t *testing.T) {
 			commits:   []*model.Commit{conventionalPatchCommit},
 			expectTag: "v0.1.1-rc.0",
 		},
-		// TODO all of these are bugs
-		// {
-		// 	name:      "patch-invalid-vanity-dot",
-		// 	tags:      []string{"v0.1.0", "v1.0.1.1"},
-		// 	commits:   []*model.Commit{conventionalPatchCommit},
-		// 	expectTag: "v0.1.1-rc.0",
-		// },
-		// {
-		// 	name:      "patch-invalid-vanity-dash",
-		// 	tags:      []string{"v0.1.0", "v1-0.1.1"},
-		// 	commits:   []*model.Commit{conventionalPatchCommit},
-		// 	expectTag: "v0.1.1-rc.0",
-		// },
-		// {
-		// 	name:      "patch-invalid-vanity-dash-v-prefix",
-		// 	tags:      []string{"v0.1.0", "v1-v0.1.1"},
-		// 	commits:   []*model.Commit{conventionalPatchCommit},
-		// 	expectTag: "v0.1.1-rc.0",
-		// },
+		{
+			name:      "patch-invalid-vanity-dot",
+			tags:      []string{"v0.1.0", "v1.0.1.1"},
+			commits:   []*model.Commit{conventionalPatchCommit},
+			expectTag: "v0.1.1-rc.0",
+		},
+		{
+			name:      "patch-invalid-vanity-dash",
+			tags:      []string{"v0.1.0", "v1-0.1.1"},
+			commits:   []*model.Commit{conventionalPatchCommit},
+			expectTag: "v0.1.1-rc.0",
+		},
+		{
+			name:      "patch-invalid-vanity-dash-v-prefix",
+			tags:      []string{"v0.1.0", "v1-v0.1.1"},
+			commits:   []*model.Commit{conventionalPatchCommit},
+			expectTag: "v0.1.1-rc.0",
+		},
 		{
 			name:      "scope",
 			tags:      []string{"cool/v0.1.0"},
@@ -407,6 +632,520 @@ func TestAnalyzeRC(t *testing.T) {
 	}
 }
 
+func TestAnalyzeRules(t *testing.T) {
+	tio, _, _ := mockTermIO(nil)
+	rules := []config.Rule{
+		{
+			Name:      "bots",
+			Condition: config.Condition{Author: `^dependabot\[bot\] `},
+			Type:      "PATCH",
+		},
+		{
+			Name:      "proto",
+			Condition: config.Condition{Paths: []string{"api/proto/**"}},
+			MinType:   "MINOR",
+		},
+		{
+			Name:      "skip",
+			Condition: config.Condition{Trailers: map[string]string{"Release": "^skip$"}},
+			Type:      "SKIP",
+		},
+	}
+	tcs := []struct {
+		name          string
+		commits       []*model.Commit
+		expectVersion string
+	}{
+		{
+			name: "bot-major",
+			commits: []*model.Commit{
+				{ID: "deadbeef", Subject: "feat: bump", Body: "BREAKING CHANGE: nope", Author: "dependabot[bot]", AuthorEmail: "bot@example.com"},
+			},
+			expectVersion: "0.1.1",
+		},
+		{
+			name: "proto-patch",
+			commits: []*model.Commit{
+				{ID: "deadbeef", Subject: "fix: proto", Files: []string{"api/proto/v1/svc.proto"}},
+			},
+			expectVersion: "0.2.0",
+		},
+		{
+			name: "proto-major",
+			commits: []*model.Commit{
+				{ID: "deadbeef", Subject: "feat: proto", Body: "BREAKING CHANGE: yes", Files: []string{"api/proto/v1/svc.proto"}},
+			},
+			expectVersion: "1.0.0",
+		},
+		{
+			name: "bot-proto",
+			commits: []*model.Commit{
+				{ID: "deadbeef", Subject: "Bump protobuf", Author: "dependabot[bot]", Files: []string{"api/proto/v1/svc.proto"}},
+			},
+			expectVersion: "0.2.0",
+		},
+		{
+			name: "trailer-skip",
+			commits: []*model.Commit{
+				{ID: "deadbeef", Subject: "fix: cool", Body: "Release: skip"},
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := newTestConfig(&config.Config{InCI: true, Rules: rules}, &tio)
+			m := vcs.NewMock().SetTags("v0.1.0").SetCommits(tc.commits...)
+			a := NewAnalyzer(cfg, m, nil)
+
+			vers, err := a.Analyze(context.Background(), "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.expectVersion == "" {
+				if len(vers) != 0 {
+					t.Fatalf("expected no versions, got %d", len(vers))
+				}
+				return
+			}
+
+			if len(vers) != 1 {
+				t.Fatalf("expected 1 version, got %d", len(vers))
+			}
+			expectVersion := semver.MustParse(tc.expectVersion)
+			if ver := vers[0]; ver.Version.NE(expectVersion) {
+				t.Errorf("expected version %s, got %s", expectVersion, ver.Version)
+			}
+		})
+	}
+}
+
+func TestAnalyzeIgnore(t *testing.T) {
+	tio, _, _ := mockTermIO(nil)
+	ignore := []config.Condition{
+		{Subject: `^Merge branch `},
+		{Subject: `\[skip release\]`},
+		{Author: `\[bot\] `},
+		{Trailers: map[string]string{"Release": "^skip$"}},
+	}
+	tcs := []struct {
+		name          string
+		commits       []*model.Commit
+		expectVersion string
+		expectCommits int
+	}{
+		{
+			name: "all-ignored",
+			commits: []*model.Commit{
+				{ID: "deadbeef", Subject: "Merge branch 'main' into cool"},
+				{ID: "12345678", Subject: "Bump cool from 1.0 to 1.1", Author: "dependabot[bot]"},
+				{ID: "abcdef12", Subject: "feat: cool [skip release]"},
+				{ID: "fedcba98", Subject: "feat: cool", Body: "Release: skip"},
+			},
+		},
+		{
+			name: "some-ignored",
+			commits: []*model.Commit{
+				{ID: "deadbeef", Subject: "fix: cool"},
+				{ID: "12345678", Subject: "feat!: bump cool", Author: "dependabot[bot]"},
+			},
+			expectVersion: "0.1.1",
+			expectCommits: 1,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := newTestConfig(&config.Config{InCI: true, Ignore: ignore}, &tio)
+			m := vcs.NewMock().SetTags("v0.1.0").SetCommits(tc.commits...)
+			a := NewAnalyzer(cfg, m, nil)
+
+			vers, err := a.Analyze(context.Background(), "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.expectVersion == "" {
+				if len(vers) != 0 {
+					t.Fatalf("expected no versions, got %d", len(vers))
+				}
+				return
+			}
+
+			if len(vers) != 1 {
+				t.Fatalf("expected 1 version, got %d", len(vers))
+			}
+			ver := vers[0]
+			expectVersion := semver.MustParse(tc.expectVersion)
+			if ver.Version.NE(expectVersion) {
+				t.Errorf("expected version %s, got %s", expectVersion, ver.Version)
+			}
+			if len(ver.AllCommits) != tc.expectCommits {
+				t.Errorf("expected %d commits, got %d", tc.expectCommits, len(ver.AllCommits))
+			}
+		})
+	}
+}
+
+func TestAnalyzeReleaseCommit(t *testing.T) {
+	tio, _, _ := mockTermIO(nil)
+	releaseCommit := &model.Commit{
+		ID:      "deadbeef",
+		Subject: "chore: release v0.1.1",
+		Body:    ReleaseCommitTrailer + ": v0.1.1\n",
+		Files:   []string{"VERSION"},
+	}
+	unmarked := &model.Commit{ID: "deadbeef", Subject: releaseCommit.Subject, Files: releaseCommit.Files}
+	tcs := []struct {
+		name          string
+		cfg           *config.Config
+		commit        *model.Commit
+		expectVersion string
+	}{
+		{
+			name:   "lax",
+			cfg:    &config.Config{Policies: []string{"lax"}},
+			commit: releaseCommit,
+		},
+		{
+			name:          "lax-unmarked",
+			cfg:           &config.Config{Policies: []string{"lax"}},
+			commit:        unmarked,
+			expectVersion: "0.1.1",
+		},
+		{
+			name:   "rules",
+			cfg:    &config.Config{Rules: []config.Rule{{Condition: config.Condition{Paths: []string{"VERSION"}}, MinType: "PATCH"}}},
+			commit: releaseCommit,
+		},
+		{
+			name:          "rules-unmarked",
+			cfg:           &config.Config{Rules: []config.Rule{{Condition: config.Condition{Paths: []string{"VERSION"}}, MinType: "PATCH"}}},
+			commit:        unmarked,
+			expectVersion: "0.1.1",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.cfg.InCI = true
+			cfg := newTestConfig(tc.cfg, &tio)
+			m := vcs.NewMock().SetTags("v0.1.0").SetCommits(tc.commit)
+			a := NewAnalyzer(cfg, m, nil)
+
+			vers, err := a.Analyze(context.Background(), "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.expectVersion == "" {
+				if len(vers) != 0 {
+					t.Fatalf("expected no versions, got %s", vers[0].Version)
+				}
+				return
+			}
+			if len(vers) != 1 {
+				t.Fatalf("expected 1 version, got %d", len(vers))
+			}
+			if expectVersion := semver.MustParse(tc.expectVersion); vers[0].Version.NE(expectVersion) {
+				t.Errorf("expected version %s, got %s", expectVersion, vers[0].Version)
+			}
+		})
+	}
+}
+
+func TestAnalyzeReleaseAs(t *testing.T) {
+	tio, _, _ := mockTermIO(nil)
+	tcs := []struct {
+		name          string
+		cfg           *config.Config
+		commits       []*model.Commit
+		expectVersion string
+		shouldFail    bool
+	}{
+		{
+			name: "basic",
+			commits: []*model.Commit{
+				{ID: "deadbeef", Subject: "fix: cool", Body: "Release-As: 2.0.0"},
+			},
+			expectVersion: "2.0.0",
+		},
+		{
+			name: "skip",
+			commits: []*model.Commit{
+				{ID: "deadbeef", Subject: "chore: cool", Body: "Release-As: v1.0.0"},
+			},
+			expectVersion: "1.0.0",
+		},
+		{
+			name: "newest-wins",
+			commits: []*model.Commit{
+				{ID: "deadbeef", Subject: "fix: cool", Body: "Release-As: 1.5.0"},
+				{ID: "12345678", Subject: "fix: cool", Body: "Release-As: 3.0.0"},
+			},
+			expectVersion: "1.5.0",
+		},
+		{
+			name: "scope",
+			cfg:  &config.Config{Scope: "cool", ReleaseScopes: []string{"cool"}},
+			commits: []*model.Commit{
+				{ID: "deadbeef", Subject: "chore: prepare", Body: "Release-As: cool@1.0.0"},
+				{ID: "12345678", Subject: "fix(cool): cool", Body: "Release-As: other@3.0.0"},
+			},
+			expectVersion: "1.0.0",
+		},
+		{
+			name: "override",
+			cfg:  &config.Config{Minor: true},
+			commits: []*model.Commit{
+				{ID: "deadbeef", Subject: "fix: cool", Body: "Release-As: 2.0.0"},
+			},
+			expectVersion: "0.2.0",
+		},
+		{
+			name: "not-greater",
+			commits: []*model.Commit{
+				{ID: "deadbeef", Subject: "fix: cool", Body: "Release-As: 0.1.0"},
+			},
+			shouldFail: true,
+		},
+		{
+			name: "invalid",
+			commits: []*model.Commit{
+				{ID: "deadbeef", Subject: "fix: cool", Body: "Release-As: next"},
+			},
+			shouldFail: true,
+		},
+		{
+			name: "prerelease",
+			commits: []*model.Commit{
+				{ID: "deadbeef", Subject: "fix: cool", Body: "Release-As: 2.0.0-rc.0"},
+			},
+			shouldFail: true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := newTestConfig(tc.cfg, &tio)
+			m := vcs.NewMock().SetTags("v0.1.0", "cool/v0.1.0").SetCommits(tc.commits...)
+			a := NewAnalyzer(cfg, m, nil)
+
+			vers, err := a.Analyze(context.Background(), "")
+			if tc.shouldFail {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				t.Log(err)
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(vers) != 1 {
+				t.Fatalf("expected 1 version, got %d", len(vers))
+			}
+			expectVersion := semver.MustParse(tc.expectVersion)
+			if ver := vers[0]; ver.Version.NE(expectVersion) {
+				t.Errorf("expected version %s, got %s", expectVersion, ver.Version)
+			}
+		})
+	}
+}
+
+func TestAnalyzePromote(t *testing.T) {
+	tio, _, _ := mockTermIO(nil)
+	tcs := []struct {
+		name          string
+		tags          []string
+		rcTag         string
+		expectVersion string
+		shouldFail    bool
+	}{
+		{
+			name:          "basic",
+			tags:          []string{"v0.1.0", "v0.2.0-rc.0"},
+			rcTag:         "v0.2.0-rc.0",
+			expectVersion: "0.2.0",
+		},
+		{
+			name:          "latest",
+			tags:          []string{"v0.1.0", "v0.2.0-rc.0", "v0.2.0-rc.1", "v0.1.1-beta.3"},
+			expectVersion: "0.2.0",
+		},
+		{
+			name:          "no-releases",
+			tags:          []string{"v0.1.0-rc.0"},
+			expectVersion: "0.1.0",
+		},
+		{
+			name:       "released",
+			tags:       []string{"v0.1.0", "v0.2.0-rc.0", "v0.2.0"},
+			rcTag:      "v0.2.0-rc.0",
+			shouldFail: true,
+		},
+		{
+			name:       "older",
+			tags:       []string{"v0.1.0", "v0.1.1-rc.0", "v0.2.0"},
+			rcTag:      "v0.1.1-rc.0",
+			shouldFail: true,
+		},
+		{
+			name:       "not-rc",
+			tags:       []string{"v0.1.0"},
+			rcTag:      "v0.1.0",
+			shouldFail: true,
+		},
+		{
+			name:       "not-found",
+			tags:       []string{"v0.1.0"},
+			rcTag:      "v0.2.0-rc.0",
+			shouldFail: true,
+		},
+		{
+			name:       "no-rcs",
+			tags:       []string{"v0.1.0"},
+			shouldFail: true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := newTestConfig(nil, &tio)
+			m := vcs.NewMock().SetTags(tc.tags...).SetCommits(conventionalPatchCommit, &model.Commit{ID: "12345678", Subject: "chore: cool"})
+			a := NewAnalyzer(cfg, m, nil)
+
+			ver, err := a.Promote(context.Background(), "", tc.rcTag)
+			if tc.shouldFail {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				t.Log(err)
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			expectVersion := semver.MustParse(tc.expectVersion)
+			if ver.Version.NE(expectVersion) {
+				t.Errorf("expected version %s, got %s", expectVersion, ver.Version)
+			}
+			if ver.Commit != "deadbeef" {
+				t.Errorf("expected commit %q, got %q", "deadbeef", ver.Commit)
+			}
+			if len(ver.AllCommits) != 2 {
+				t.Errorf("expected 2 commits, got %d", len(ver.AllCommits))
+			}
+		})
+	}
+}
+
+func TestAnalyzePrereleaseChannels(t *testing.T) {
+	tio, _, _ := mockTermIO(nil)
+	tcs := []struct {
+		name       string
+		tags       []string
+		rc         string
+		expectTag  string
+		shouldFail bool
+	}{
+		{
+			name:      "first",
+			tags:      []string{"v0.1.0"},
+			rc:        "alpha",
+			expectTag: "v0.1.1-alpha.0",
+		},
+		{
+			name:      "same",
+			tags:      []string{"v0.1.0", "v0.1.1-alpha.0", "v0.1.1-alpha.1"},
+			rc:        "alpha",
+			expectTag: "v0.1.1-alpha.2",
+		},
+		{
+			name:      "later",
+			tags:      []string{"v0.1.0", "v0.1.1-alpha.0", "v0.1.1-alpha.1"},
+			rc:        "rc",
+			expectTag: "v0.1.1-rc.0",
+		},
+		{
+			name:      "earlier-other-version",
+			tags:      []string{"v0.1.0", "v0.1.0-rc.0"},
+			rc:        "alpha",
+			expectTag: "v0.1.1-alpha.0",
+		},
+		{
+			name:       "earlier",
+			tags:       []string{"v0.1.0", "v0.1.1-alpha.0", "v0.1.1-beta.0"},
+			rc:         "alpha",
+			shouldFail: true,
+		},
+		{
+			name:       "unknown",
+			tags:       []string{"v0.1.0"},
+			rc:         "preview",
+			shouldFail: true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := newTestConfig(&config.Config{PrereleaseChannels: []string{"alpha", "beta", "rc"}}, &tio)
+			tag, err := NewTagFromConfig(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			m := vcs.NewMock().SetTags(tc.tags...).SetCommits(conventionalPatchCommit)
+			a := NewAnalyzer(cfg, m, tag)
+
+			vers, err := a.Analyze(context.Background(), tc.rc)
+			if tc.shouldFail {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				t.Log(err)
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(vers) != 1 {
+				t.Fatalf("expected 1 version, got %d", len(vers))
+			}
+			got, err := tag.ExecuteString(TagData{Version: vers[0]})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.expectTag {
+				t.Errorf("expected tag %q, got %q", tc.expectTag, got)
+			}
+		})
+	}
+}
+
+func TestMatchRulesNoPolicy(t *testing.T) {
+	tio, _, _ := mockTermIO(nil)
+	cfg := newTestConfig(&config.Config{
+		Policies: []string{"conventional-lax"},
+		Rules: []config.Rule{
+			{Condition: config.Condition{Committer: "^GitHub "}, Type: "PATCH"},
+		},
+	}, &tio)
+	a := NewAnalyzer(cfg, vcs.NewMock(), nil)
+
+	ac, err := a.Match(&model.Commit{ID: "deadbeef", Subject: "Merge pull request #1", Committer: "GitHub", CommitterEmail: "noreply@github.com"}, cfg.GetPolicies())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ac.ReleaseType != ReleasePatch {
+		t.Errorf("expected release type %s, got %s", ReleasePatch, ac.ReleaseType)
+	}
+	if len(ac.Rules) != 1 || ac.Rules[0] != "#1" {
+		t.Errorf("expected rule #1 to match, got %q", ac.Rules)
+	}
+
+	if _, err := a.Match(&model.Commit{ID: "deadbeef", Subject: "whatever"}, cfg.GetPolicies()); err == nil {
+		t.Fatal("expected no matching policy error")
+	}
+}
+
 func mockTermIO(stdin io.Reader) (config.TerminalIO, *bytes.Buffer, *bytes.Buffer) {
 	ob := &bytes.Buffer{}
 	eb := &bytes.Buffer{}
@@ -419,3 +1158,306 @@ func newTestConfig(overrides *config.Config, tio *config.TerminalIO) config.Conf
 	cfg.IgnorePolicies = true
 	return cfg
 }
+
+func TestAnalyzeLegacyTagTemplates(t *testing.T) {
+	tio, _, _ := mockTermIO(nil)
+	tcs := []struct {
+		name      string
+		cfg       *config.Config
+		tags      []string
+		commits   []*model.Commit
+		rc        string
+		expectTag string
+	}{
+		{
+			name:      "legacy-only",
+			cfg:       &config.Config{LegacyTagTemplates: []string{"{{ semver .Version }}"}},
+			tags:      []string{"1.2.2", "1.2.3"},
+			commits:   []*model.Commit{conventionalPatchCommit},
+			expectTag: "v1.2.4",
+		},
+		{
+			name:      "both",
+			cfg:       &config.Config{LegacyTagTemplates: []string{"{{ semver .Version }}"}},
+			tags:      []string{"1.2.3", "v1.3.0", "1.2.9"},
+			commits:   []*model.Commit{conventionalPatchCommit},
+			expectTag: "v1.3.1",
+		},
+		{
+			name:      "legacy-newer",
+			cfg:       &config.Config{LegacyTagTemplates: []string{"{{ semver .Version }}"}},
+			tags:      []string{"v1.1.0", "1.2.3"},
+			commits:   []*model.Commit{conventionalMinorCommit},
+			expectTag: "v1.3.0",
+		},
+		{
+			name:      "legacy-rc",
+			cfg:       &config.Config{LegacyTagTemplates: []string{"{{ semver .Version }}"}},
+			tags:      []string{"1.2.3", "1.2.4-rc.0"},
+			commits:   []*model.Commit{conventionalPatchCommit},
+			rc:        "rc",
+			expectTag: "v1.2.4-rc.0",
+		},
+		{
+			name: "scope-prefix",
+			cfg: &config.Config{
+				Scope:              "cool",
+				LegacyTagTemplates: []string{"v{{ semver .Version }}"},
+			},
+			tags:      []string{"v1.2.3", "cool/v0.1.0"},
+			commits:   []*model.Commit{conventionalScopedPatchCommit},
+			expectTag: "cool/v1.2.4",
+		},
+		{
+			name: "scope-config",
+			cfg: &config.Config{
+				Scope:  "cool",
+				Scopes: map[string]config.ScopeConfig{"cool": {LegacyTagTemplates: []string{"cool-{{ semver .Version }}"}}},
+			},
+			tags:      []string{"v2.0.0", "cool-1.2.3"},
+			commits:   []*model.Commit{conventionalScopedPatchCommit},
+			expectTag: "cool/v1.2.4",
+		},
+		{
+			name:      "other-format-ignored",
+			cfg:       &config.Config{LegacyTagTemplates: []string{"release-{{ semver .Version }}"}},
+			tags:      []string{"v1.2.3", "1.9.0", "release-1.2.5"},
+			commits:   []*model.Commit{conventionalPatchCommit},
+			expectTag: "v1.2.6",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := newTestConfig(tc.cfg, &tio)
+			tag, err := NewTagFromConfig(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			m := vcs.NewMock().SetTags(tc.tags...).SetCommits(tc.commits...)
+			a := NewAnalyzer(cfg, m, tag)
+
+			vers, err := a.Analyze(context.Background(), tc.rc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(vers) != 1 {
+				t.Fatalf("expected 1 version, got %d", len(vers))
+			}
+			got, err := tag.ExecuteString(TagData{Version: vers[0]})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.expectTag {
+				t.Errorf("expected tag %q, got %q", tc.expectTag, got)
+			}
+		})
+	}
+}
+
+func TestAnalyzeDependencies(t *testing.T) {
+	tio, _, _ := mockTermIO(nil)
+	scopes := map[string]config.ScopeConfig{
+		"app": {DependsOn: []string{"cli"}},
+		"cli": {DependsOn: []string{"sdk"}},
+	}
+	tags := []string{"v0.1.0", "sdk/v1.4.0", "cli/v2.0.0", "app/v0.3.0"}
+	tcs := []struct {
+		name       string
+		cfg        *config.Config
+		commits    []*model.Commit
+		expectTags []string
+		expectDeps map[string][]string
+		shouldFail bool
+	}{
+		{
+			name:       "dependency",
+			cfg:        &config.Config{All: true, ReleaseScopes: []string{"app", "cli", "sdk"}, Scopes: scopes},
+			commits:    []*model.Commit{{ID: "deadbeef", Subject: "feat(sdk): cool feature"}},
+			expectTags: []string{"sdk/v1.5.0", "cli/v2.0.1", "app/v0.3.1"},
+			expectDeps: map[string][]string{"cli": {"sdk/v1.5.0"}, "app": {"cli/v2.0.1"}},
+		},
+		{
+			name: "dependent-changes",
+			cfg:  &config.Config{All: true, ReleaseScopes: []string{"app", "cli", "sdk"}, Scopes: scopes},
+			commits: []*model.Commit{
+				{ID: "deadbeef", Subject: "fix(sdk): cool fix"},
+				{ID: "12345678", Subject: "feat(cli): cool feature"},
+			},
+			expectTags: []string{"sdk/v1.4.1", "cli/v2.1.0", "app/v0.3.1"},
+			expectDeps: map[string][]string{"cli": {"sdk/v1.4.1"}, "app": {"cli/v2.1.0"}},
+		},
+		{
+			name:       "dependent-only",
+			cfg:        &config.Config{All: true, ReleaseScopes: []string{"app", "cli", "sdk"}, Scopes: scopes},
+			commits:    []*model.Commit{{ID: "deadbeef", Subject: "fix(cli): cool fix"}},
+			expectTags: []string{"cli/v2.0.1", "app/v0.3.1"},
+			expectDeps: map[string][]string{"app": {"cli/v2.0.1"}},
+		},
+		{
+			name:       "single-scope",
+			cfg:        &config.Config{Scope: "sdk", ReleaseScopes: []string{"app", "cli", "sdk"}, Scopes: scopes},
+			commits:    []*model.Commit{{ID: "deadbeef", Subject: "feat(sdk): cool feature"}},
+			expectTags: []string{"sdk/v1.5.0"},
+		},
+		{
+			name: "cycle",
+			cfg: &config.Config{All: true, ReleaseScopes: []string{"cli", "sdk"}, Scopes: map[string]config.ScopeConfig{
+				"cli": {DependsOn: []string{"sdk"}},
+				"sdk": {DependsOn: []string{"cli"}},
+			}},
+			commits:    []*model.Commit{{ID: "deadbeef", Subject: "feat(sdk): cool feature"}},
+			shouldFail: true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := newTestConfig(tc.cfg, &tio)
+			tag, err := NewTagFromConfig(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			m := vcs.NewMock().SetTags(tags...).SetCommits(tc.commits...)
+			a := NewAnalyzer(cfg, m, tag)
+
+			vers, err := a.Analyze(context.Background(), "")
+			if tc.shouldFail {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, ver := range vers {
+				gotTag, err := tag.ExecuteString(TagData{Version: ver})
+				if err != nil {
+					t.Fatal(err)
+				}
+				got = append(got, gotTag)
+
+				var deps []string
+				for _, dep := range ver.Dependencies {
+					depTag, err := tag.ExecuteString(TagData{Version: dep})
+					if err != nil {
+						t.Fatal(err)
+					}
+					deps = append(deps, depTag)
+				}
+				if strings.Join(deps, ",") != strings.Join(tc.expectDeps[ver.Scope], ",") {
+					t.Errorf("expected %s dependencies %q, got %q", ver.Scope, tc.expectDeps[ver.Scope], deps)
+				}
+				if ver.Commit == "" {
+					t.Errorf("expected %s to have a commit", ver.Scope)
+				}
+			}
+			if strings.Join(got, ",") != strings.Join(tc.expectTags, ",") {
+				t.Errorf("expected tags %q, got %q", tc.expectTags, got)
+			}
+		})
+	}
+}
+
+func TestAnalyzeMultipleScopes(t *testing.T) {
+	tio, _, _ := mockTermIO(nil)
+	tags := []string{"v0.1.0", "api/v1.0.0", "cli/v2.0.0"}
+	tcs := []struct {
+		name         string
+		cfg          *config.Config
+		commits      []*model.Commit
+		expectTags   []string
+		expectScoped map[string]int
+	}{
+		{
+			name:         "both",
+			cfg:          &config.Config{All: true, ReleaseScopes: []string{"api", "cli"}},
+			commits:      []*model.Commit{{ID: "deadbeef", Subject: "feat(api,cli): cool feature"}},
+			expectTags:   []string{"api/v1.1.0", "cli/v2.1.0"},
+			expectScoped: map[string]int{"api": 1, "cli": 1},
+		},
+		{
+			name: "mixed",
+			cfg:  &config.Config{All: true, ReleaseScopes: []string{"api", "cli"}},
+			commits: []*model.Commit{
+				{ID: "deadbeef", Subject: "fix(api, cli): cool fix"},
+				{ID: "12345678", Subject: "feat(cli): cool feature"},
+				{ID: "abcdef12", Subject: "fix: cool fix"},
+			},
+			expectTags:   []string{"v0.1.1", "api/v1.0.1", "cli/v2.1.0"},
+			expectScoped: map[string]int{"": 1, "api": 1, "cli": 2},
+		},
+		{
+			name:         "separator",
+			cfg:          &config.Config{All: true, ReleaseScopes: []string{"api", "cli"}, ScopeSeparator: "+"},
+			commits:      []*model.Commit{{ID: "deadbeef", Subject: "fix(api+cli): cool fix"}},
+			expectTags:   []string{"api/v1.0.1", "cli/v2.0.1"},
+			expectScoped: map[string]int{"api": 1, "cli": 1},
+		},
+		{
+			name:         "rule",
+			cfg:          &config.Config{All: true, ReleaseScopes: []string{"api", "cli"}, Rules: []config.Rule{{Condition: config.Condition{Scope: "cli"}, MinType: "MINOR"}}},
+			commits:      []*model.Commit{{ID: "deadbeef", Subject: "fix(api,cli): cool fix"}},
+			expectTags:   []string{"api/v1.1.0", "cli/v2.1.0"},
+			expectScoped: map[string]int{"api": 1, "cli": 1},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := newTestConfig(tc.cfg, &tio)
+			tag, err := NewTagFromConfig(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			m := vcs.NewMock().SetTags(tags...).SetCommits(tc.commits...)
+			a := NewAnalyzer(cfg, m, tag)
+
+			vers, err := a.Analyze(context.Background(), "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, ver := range vers {
+				gotTag, err := tag.ExecuteString(TagData{Version: ver})
+				if err != nil {
+					t.Fatal(err)
+				}
+				got = append(got, gotTag)
+				if n := len(ver.ScopedCommits()); n != tc.expectScoped[ver.Scope] {
+					t.Errorf("expected %d commits in scope %q, got %d", tc.expectScoped[ver.Scope], ver.Scope, n)
+				}
+			}
+			if strings.Join(got, ",") != strings.Join(tc.expectTags, ",") {
+				t.Errorf("expected tags %q, got %q", tc.expectTags, got)
+			}
+		})
+	}
+}
+
+func TestAnalyzerPolicies(t *testing.T) {
+	tio, _, _ := mockTermIO(nil)
+	cfg := newTestConfig(&config.Config{
+		Policies: []string{"conventional"},
+		Scopes:   map[string]config.ScopeConfig{"api": {Policies: []string{"angular"}}},
+	}, &tio)
+	a := NewAnalyzer(cfg, vcs.NewMock(), nil)
+	sa, err := a.ForScope("api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, an := range []*Analyzer{a, sa} {
+		pols := an.Policies()
+		if len(pols) == 0 {
+			t.Fatal("expected policies")
+		}
+		if again := an.Policies(); again[0] != pols[0] {
+			t.Error("expected policies to be resolved once")
+		}
+	}
+	if a.Policies()[0].Name != "conventional" || sa.Policies()[0].Name != "angular" {
+		t.Errorf("expected conventional and angular policies, got %q and %q", a.Policies()[0].Name, sa.Policies()[0].Name)
+	}
+}
@@ -0,0 +1,84 @@
+package commit
+
+import (
+	"context"
+	"errors"
+
+	"github.com/blang/semver/v4"
+)
+
+// groupLatest returns the latest release of the scope's version group, which
+// is latest if the scope isn't in a group. Scopes of the group without
+// releases are skipped.
+func (a *Analyzer) groupLatest(ctx context.Context, scope string, latest semver.Version) (semver.Version, error) {
+	for _, member := range a.cfg.VersionGroup(scope) {
+		if member == scope {
+			continue
+		}
+		v, err := a.LatestRelease(ctx, member, "")
+		if err != nil {
+			if errors.Is(err, ErrNoTags) {
+				continue
+			}
+			return semver.Version{}, err
+		}
+		if compareVersions(a.tag.scheme, v, latest) > 0 {
+			a.cfg.Debugf("version group of %q is at %s (scope: %q)", scope, v, member)
+			latest = a.tag.Release(v)
+		}
+	}
+	return latest, nil
+}
+
+// alignGroup releases each scope of a version group at the highest version
+// any of them would be released at. vers are the versions of members, which
+// are nil for scopes without releaseable commits.
+func (a *Analyzer) alignGroup(ctx context.Context, members []string, vers []*Version, rc string, line *MaintenanceLine) ([]*Version, error) {
+	var target *Version
+	var released []*Version
+	for _, ver := range vers {
+		if ver == nil {
+			continue
+		}
+		released = append(released, ver)
+		if target == nil || compareVersions(a.tag.scheme, ver.Version, target.Version) > 0 {
+			target = ver
+		}
+	}
+	if target == nil {
+		return nil, nil
+	}
+	next := target.Version
+	next.Pre = nil
+
+	res := make([]*Version, len(members))
+	for i, member := range members {
+		sa, err := a.ForScope(member)
+		if err != nil {
+			return nil, err
+		}
+		ver := vers[i]
+		if ver != nil && compareVersions(sa.tag.scheme, ver.Version, next) == 0 {
+			res[i] = ver
+			continue
+		}
+		if ver == nil {
+			ver = &Version{Commit: latestCommit(released), Scope: member}
+		}
+		v := next
+		if rc != "" {
+			pre, err := sa.prerelease(ctx, member, rc, v)
+			if err != nil {
+				return nil, err
+			}
+			v.Pre = pre
+		}
+		if err := checkMaintenanceLine(line, v); err != nil {
+			return nil, err
+		}
+		sa.cfg.Debugf("version group releases %s (scope: %q)", v, member)
+		ver.Version = v
+		res[i] = ver
+	}
+	return res, nil
+}
@@ -0,0 +1,110 @@
+package commit
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jeffrom/tunk/config"
+	"github.com/jeffrom/tunk/model"
+	"github.com/jeffrom/tunk/vcs"
+)
+
+func TestAnalyzeVersionGroups(t *testing.T) {
+	tio, _, _ := mockTermIO(nil)
+	groups := map[string][]string{"platform": {"client", "server"}}
+	tcs := []struct {
+		name       string
+		cfg        *config.Config
+		tags       []string
+		commits    []*model.Commit
+		rc         string
+		expectTags []string
+	}{
+		{
+			name:       "one-member",
+			cfg:        &config.Config{All: true, ReleaseScopes: []string{"client", "server"}},
+			tags:       []string{"v0.1.0", "client/v1.2.0", "server/v1.2.0"},
+			commits:    []*model.Commit{{ID: "deadbeef", Subject: "feat(client): cool feature"}},
+			expectTags: []string{"client/v1.3.0", "server/v1.3.0"},
+		},
+		{
+			name: "highest-release-type",
+			cfg:  &config.Config{All: true, ReleaseScopes: []string{"client", "server"}},
+			tags: []string{"v0.1.0", "client/v1.2.0", "server/v1.2.0"},
+			commits: []*model.Commit{
+				{ID: "deadbeef", Subject: "feat(server): cool feature"},
+				{ID: "12345678", Subject: "fix(client): cool fix"},
+			},
+			expectTags: []string{"client/v1.3.0", "server/v1.3.0"},
+		},
+		{
+			name:       "highest-version",
+			cfg:        &config.Config{All: true, ReleaseScopes: []string{"client", "server"}},
+			tags:       []string{"v0.1.0", "client/v1.2.0", "server/v1.3.0"},
+			commits:    []*model.Commit{{ID: "deadbeef", Subject: "fix(client): cool fix"}},
+			expectTags: []string{"client/v1.3.1", "server/v1.3.1"},
+		},
+		{
+			name:       "single-scope",
+			cfg:        &config.Config{Scope: "server", ReleaseScopes: []string{"client", "server"}},
+			tags:       []string{"v0.1.0", "client/v1.2.0", "server/v1.2.0"},
+			commits:    []*model.Commit{{ID: "deadbeef", Subject: "fix(server): cool fix"}},
+			expectTags: []string{"client/v1.2.1", "server/v1.2.1"},
+		},
+		{
+			name: "other-scopes",
+			cfg:  &config.Config{All: true, ReleaseScopes: []string{"client", "sdk", "server"}},
+			tags: []string{"v0.1.0", "client/v1.2.0", "server/v1.2.0", "sdk/v0.4.0"},
+			commits: []*model.Commit{
+				{ID: "deadbeef", Subject: "fix(server): cool fix"},
+				{ID: "12345678", Subject: "feat(sdk): cool feature"},
+				{ID: "abcdef12", Subject: "fix: cool fix"},
+			},
+			expectTags: []string{"v0.1.1", "client/v1.2.1", "server/v1.2.1", "sdk/v0.5.0"},
+		},
+		{
+			name:       "rc",
+			cfg:        &config.Config{All: true, ReleaseScopes: []string{"client", "server"}},
+			tags:       []string{"v0.1.0", "client/v1.2.0", "server/v1.2.0", "server/v1.3.0-rc.0"},
+			commits:    []*model.Commit{{ID: "deadbeef", Subject: "feat(client): cool feature"}},
+			rc:         "rc",
+			expectTags: []string{"client/v1.3.0-rc.0", "server/v1.3.0-rc.1"},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.cfg.VersionGroups = groups
+			cfg := newTestConfig(tc.cfg, &tio)
+			if err := cfg.Validate(); err != nil {
+				t.Fatal(err)
+			}
+			tag, err := NewTagFromConfig(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			m := vcs.NewMock().SetTags(tc.tags...).SetCommits(tc.commits...)
+			a := NewAnalyzer(cfg, m, tag)
+
+			vers, err := a.Analyze(context.Background(), tc.rc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, ver := range vers {
+				gotTag, err := tag.ExecuteString(TagData{Version: ver})
+				if err != nil {
+					t.Fatal(err)
+				}
+				got = append(got, gotTag)
+				if ver.Commit == "" {
+					t.Errorf("expected %s to have a commit", gotTag)
+				}
+			}
+			if strings.Join(got, ",") != strings.Join(tc.expectTags, ",") {
+				t.Errorf("expected tags %q, got %q", tc.expectTags, got)
+			}
+		})
+	}
+}
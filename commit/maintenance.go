@@ -0,0 +1,77 @@
+package commit
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/blang/semver/v4"
+)
+
+// MaintenanceLine is the version line a maintenance branch releases, either a
+// major version, such as 1.x, or a minor version, such as 1.4.x.
+type MaintenanceLine struct {
+	Branch string
+	Major  uint64
+	Minor  uint64
+	// MinorLine is true when the line is a minor version.
+	MinorLine bool
+}
+
+// maintenanceLineRE reads the line from the end of a branch name, such as
+// release/1.x, release/v1.4, or release-1.4.x.
+var maintenanceLineRE = regexp.MustCompile(`(?:^|[^0-9A-Za-z.])v?(0|[1-9]\d*)(?:\.(0|[1-9]\d*))?(?:\.x)?$`)
+
+// ParseMaintenanceLine reads the version line from a maintenance branch name.
+func ParseMaintenanceLine(branch string) (*MaintenanceLine, error) {
+	m := maintenanceLineRE.FindStringSubmatch(branch)
+	if m == nil {
+		return nil, fmt.Errorf("maintenance branch %q doesn't end in a version line, such as 1.x or v1.4", branch)
+	}
+	line := &MaintenanceLine{Branch: branch, Major: mustParseUint(m[1])}
+	if m[2] != "" {
+		line.Minor = mustParseUint(m[2])
+		line.MinorLine = true
+	}
+	return line, nil
+}
+
+func (l *MaintenanceLine) String() string {
+	if l.MinorLine {
+		return fmt.Sprintf("%d.%d.x", l.Major, l.Minor)
+	}
+	return fmt.Sprintf("%d.x", l.Major)
+}
+
+// Contains returns true if v is in the line.
+func (l *MaintenanceLine) Contains(v semver.Version) bool {
+	if v.Major != l.Major {
+		return false
+	}
+	return !l.MinorLine || v.Minor == l.Minor
+}
+
+// maintenanceLine returns the version line of the current branch, or nil if it
+// isn't a maintenance branch.
+func (a *Analyzer) maintenanceLine(ctx context.Context) (*MaintenanceLine, error) {
+	if len(a.cfg.MaintenanceBranches) == 0 {
+		return nil, nil
+	}
+	branch, err := a.vcs.CurrentBranch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !a.cfg.IsMaintenanceBranch(branch) {
+		return nil, nil
+	}
+	return ParseMaintenanceLine(branch)
+}
+
+// checkMaintenanceLine refuses releases that would leave the maintenance
+// line.
+func checkMaintenanceLine(line *MaintenanceLine, v semver.Version) error {
+	if line == nil || line.Contains(v) {
+		return nil
+	}
+	return fmt.Errorf("release %s would leave the %s line of maintenance branch %q", v, line, line.Branch)
+}
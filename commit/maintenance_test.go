@@ -0,0 +1,147 @@
+package commit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/blang/semver/v4"
+
+	"github.com/jeffrom/tunk/config"
+	"github.com/jeffrom/tunk/model"
+	"github.com/jeffrom/tunk/vcs"
+)
+
+func TestParseMaintenanceLine(t *testing.T) {
+	tcs := []struct {
+		branch     string
+		expect     string
+		shouldFail bool
+	}{
+		{branch: "release/1.x", expect: "1.x"},
+		{branch: "release/v1.x", expect: "1.x"},
+		{branch: "release/v1", expect: "1.x"},
+		{branch: "release/v1.4", expect: "1.4.x"},
+		{branch: "release/1.4.x", expect: "1.4.x"},
+		{branch: "lts-0.9", expect: "0.9.x"},
+		{branch: "2.x", expect: "2.x"},
+		{branch: "release/next", shouldFail: true},
+		{branch: "release/1.4.2", shouldFail: true},
+		{branch: "release/01.x", shouldFail: true},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.branch, func(t *testing.T) {
+			line, err := ParseMaintenanceLine(tc.branch)
+			if tc.shouldFail {
+				if err == nil {
+					t.Fatalf("expected error, got line %s", line)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if line.String() != tc.expect {
+				t.Errorf("expected line %q, got %q", tc.expect, line)
+			}
+		})
+	}
+}
+
+func TestAnalyzeMaintenanceBranch(t *testing.T) {
+	tio, _, _ := mockTermIO(nil)
+	breakingCommit := &model.Commit{ID: "deadbeef", Subject: "feat: cool", Body: "BREAKING CHANGE: cool"}
+	featCommit := &model.Commit{ID: "deadbeef", Subject: "feat: cool"}
+	tcs := []struct {
+		name          string
+		cfg           *config.Config
+		branch        string
+		commit        *model.Commit
+		expectVersion string
+		shouldFail    bool
+	}{
+		{
+			name:          "major-line",
+			branch:        "release/1.x",
+			commit:        featCommit,
+			expectVersion: "1.2.0",
+		},
+		{
+			name:          "minor-line",
+			branch:        "release/v1.0",
+			commit:        conventionalPatchCommit,
+			expectVersion: "1.0.2",
+		},
+		{
+			name:          "main",
+			branch:        "main",
+			commit:        featCommit,
+			expectVersion: "2.1.0",
+		},
+		{
+			name:          "not-maintenance",
+			branch:        "feature/1.x",
+			commit:        featCommit,
+			expectVersion: "2.1.0",
+		},
+		{
+			name:       "leave-major-line",
+			branch:     "release/1.x",
+			commit:     breakingCommit,
+			shouldFail: true,
+		},
+		{
+			name:       "leave-minor-line",
+			branch:     "release/v1.0",
+			commit:     featCommit,
+			shouldFail: true,
+		},
+		{
+			name:       "override",
+			cfg:        &config.Config{Major: true},
+			branch:     "release/1.x",
+			commit:     featCommit,
+			shouldFail: true,
+		},
+		{
+			name:       "no-releases",
+			branch:     "release/3.x",
+			commit:     featCommit,
+			shouldFail: true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			overrides := tc.cfg
+			if overrides == nil {
+				overrides = &config.Config{}
+			}
+			overrides.MaintenanceBranches = []string{"release/*"}
+			cfg := newTestConfig(overrides, &tio)
+			m := vcs.NewMock().SetBranch(tc.branch).
+				SetTags("v1.0.0", "v1.0.1", "v1.1.0", "v2.0.0").
+				SetCommits(tc.commit)
+			a := NewAnalyzer(cfg, m, nil)
+
+			vers, err := a.Analyze(context.Background(), "")
+			if tc.shouldFail {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				t.Log(err)
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(vers) != 1 {
+				t.Fatalf("expected 1 version, got %d", len(vers))
+			}
+			expectVersion := semver.MustParse(tc.expectVersion)
+			if ver := vers[0]; ver.Version.NE(expectVersion) {
+				t.Errorf("expected version %s, got %s", expectVersion, ver.Version)
+			}
+		})
+	}
+}
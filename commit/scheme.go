@@ -0,0 +1,301 @@
+package commit
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blang/semver/v4"
+)
+
+// Scheme is a versioning scheme. Versions of every scheme are stored as
+// semver.Version, with the scheme's three components in the major, minor, and
+// patch fields, so tunk's prerelease handling and version ordering work the
+// same way for all of them.
+type Scheme interface {
+	Name() string
+	// Format renders the version's three components, without any prerelease or
+	// build metadata.
+	Format(v semver.Version) string
+	// Extract reads a version from a tag.
+	Extract(tag string) (semver.Version, error)
+	// Bump returns the version following curr.
+	Bump(curr semver.Version, releaseType ReleaseType, now time.Time) semver.Version
+	// Initial returns the version suggested for a first release.
+	Initial(now time.Time) semver.Version
+}
+
+// Version scheme names.
+const (
+	SchemeSemver   = "semver"
+	SchemeCalver   = "calver"
+	SchemeFourPart = "four_part"
+)
+
+// DefaultCalverFormat is used when the calver scheme is configured without a
+// format.
+const DefaultCalverFormat = "YYYY.MM.MICRO"
+
+// NewScheme returns the named scheme. format is only used by calver.
+func NewScheme(name, format string) (Scheme, error) {
+	switch name {
+	case "", SchemeSemver:
+		return SemverScheme{}, nil
+	case SchemeCalver:
+		if format == "" {
+			format = DefaultCalverFormat
+		}
+		return NewCalverScheme(format)
+	case SchemeFourPart:
+		return FourPartScheme{}, nil
+	}
+	return nil, fmt.Errorf("unknown version scheme %q", name)
+}
+
+// SemverScheme is the default, Semantic Versioning scheme.
+type SemverScheme struct{}
+
+func (SemverScheme) Name() string { return SchemeSemver }
+
+func (SemverScheme) Format(v semver.Version) string {
+	v.Pre = nil
+	v.Build = nil
+	return v.String()
+}
+
+func (SemverScheme) Extract(tag string) (semver.Version, error) { return extractSemver(tag) }
+
+func (SemverScheme) Bump(curr semver.Version, releaseType ReleaseType, now time.Time) semver.Version {
+	return bumpVersion(curr, releaseType)
+}
+
+func (SemverScheme) Initial(now time.Time) semver.Version { return semver.Version{Minor: 1} }
+
+// CalverScheme is a Calendar Versioning scheme, such as YYYY.MM.MICRO. The
+// first component is the year, the second the month or week, and the third is
+// incremented for each release in the same period. See
+// https://calver.org/#scheme.
+type CalverScheme struct {
+	format string
+	parts  [3]string
+	re     *regexp.Regexp
+}
+
+var calverTokenREs = map[string]string{
+	"YYYY":  `[1-9]\d{3}`,
+	"YY":    `0|[1-9]\d{0,2}`,
+	"0Y":    `\d{2,3}`,
+	"MM":    `1[0-2]|[1-9]`,
+	"0M":    `0[1-9]|1[0-2]`,
+	"WW":    `5[0-3]|[1-4]\d|[1-9]`,
+	"0W":    `5[0-3]|[1-4]\d|0[1-9]`,
+	"MICRO": `0|[1-9]\d*`,
+	"N":     `0|[1-9]\d*`,
+}
+
+var calverPositions = [3][]string{
+	{"YYYY", "YY", "0Y"},
+	{"MM", "0M", "WW", "0W"},
+	{"MICRO", "N"},
+}
+
+// NewCalverScheme returns a calver scheme for format, which has three
+// dot-separated components: a year (YYYY, YY, or 0Y), a month or week (MM,
+// 0M, WW, or 0W), and a release counter (MICRO or N).
+func NewCalverScheme(format string) (*CalverScheme, error) {
+	parts := strings.Split(format, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("calver format %q must have three components, such as %q", format, DefaultCalverFormat)
+	}
+	s := &CalverScheme{format: format}
+	var groups []string
+	for i, part := range parts {
+		if !inStrs(part, calverPositions[i]) {
+			return nil, fmt.Errorf("calver format %q: component %d must be one of %s", format, i+1, strings.Join(calverPositions[i], ", "))
+		}
+		s.parts[i] = part
+		groups = append(groups, fmt.Sprintf(`(%s)`, calverTokenREs[part]))
+	}
+	s.re = regexp.MustCompile(`(?:^|[^0-9.])` + strings.Join(groups, `\.`) +
+		`(?:-([A-Za-z\d]+)\.(0|[1-9]\d*))?(?:\+([0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*))?(?:[^0-9A-Za-z.+-]|$)`)
+	return s, nil
+}
+
+func (s *CalverScheme) Name() string { return SchemeCalver }
+
+func (s *CalverScheme) Format(v semver.Version) string {
+	nums := [3]uint64{v.Major, v.Minor, v.Patch}
+	res := make([]string, 3)
+	for i, part := range s.parts {
+		if strings.HasPrefix(part, "0") {
+			res[i] = fmt.Sprintf("%02d", nums[i])
+		} else {
+			res[i] = strconv.FormatUint(nums[i], 10)
+		}
+	}
+	return strings.Join(res, ".")
+}
+
+func (s *CalverScheme) Extract(tag string) (semver.Version, error) {
+	matches := s.re.FindAllStringSubmatch(tag, -1)
+	if len(matches) == 0 {
+		return semver.Version{}, errInvalidSemver
+	}
+	m := matches[len(matches)-1]
+
+	var nums [3]uint64
+	for i := range nums {
+		n, err := strconv.ParseUint(m[i+1], 10, 64)
+		if err != nil {
+			return semver.Version{}, err
+		}
+		nums[i] = n
+	}
+	v := semver.Version{Major: nums[0], Minor: nums[1], Patch: nums[2]}
+	if m[4] != "" {
+		v.Pre = []semver.PRVersion{
+			{VersionStr: m[4]},
+			{VersionNum: mustParseUint(m[5]), IsNum: true},
+		}
+	}
+	if m[6] != "" {
+		v.Build = strings.Split(m[6], ".")
+	}
+	return v, nil
+}
+
+// Bump returns the first version of the current period, or if curr is already
+// in it, increments the release counter. The release type doesn't matter.
+func (s *CalverScheme) Bump(curr semver.Version, releaseType ReleaseType, now time.Time) semver.Version {
+	next := s.Initial(now)
+	if next.Major < curr.Major || (next.Major == curr.Major && next.Minor <= curr.Minor) {
+		next = curr
+		next.Pre = nil
+		next.Build = nil
+		next.Patch++
+	}
+	return next
+}
+
+func (s *CalverScheme) Initial(now time.Time) semver.Version {
+	year, month := now.Year(), int(now.Month())
+	if s.parts[1] == "WW" || s.parts[1] == "0W" {
+		year, month = now.ISOWeek()
+	}
+	if s.parts[0] != "YYYY" {
+		year -= 2000
+	}
+	return semver.Version{Major: uint64(year), Minor: uint64(month)}
+}
+
+// FourPartScheme is a MAJOR.MINOR.PATCH.BUILD scheme, as used for Windows file
+// versions and firmware images. The first three components are bumped as in
+// semver, and BUILD is incremented by every release and never reset, so it
+// always identifies a single release. BUILD is stored as the version's first
+// build identifier, with any build metadata after it.
+type FourPartScheme struct{}
+
+var fourPartRE = regexp.MustCompile(`(?:^|[^0-9.])(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)` +
+	`(?:-([A-Za-z\d]+)\.(0|[1-9]\d*))?(?:\+([0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*))?(?:[^0-9A-Za-z.+-]|$)`)
+
+func (FourPartScheme) Name() string { return SchemeFourPart }
+
+func (FourPartScheme) Format(v semver.Version) string {
+	return fmt.Sprintf("%d.%d.%d.%d", v.Major, v.Minor, v.Patch, fourPartBuild(v))
+}
+
+func (FourPartScheme) Extract(tag string) (semver.Version, error) {
+	matches := fourPartRE.FindAllStringSubmatch(tag, -1)
+	if len(matches) == 0 {
+		return semver.Version{}, errInvalidSemver
+	}
+	m := matches[len(matches)-1]
+
+	v := semver.Version{
+		Major: mustParseUint(m[1]),
+		Minor: mustParseUint(m[2]),
+		Patch: mustParseUint(m[3]),
+		Build: []string{m[4]},
+	}
+	if m[5] != "" {
+		v.Pre = []semver.PRVersion{
+			{VersionStr: m[5]},
+			{VersionNum: mustParseUint(m[6]), IsNum: true},
+		}
+	}
+	if m[7] != "" {
+		v.Build = append(v.Build, strings.Split(m[7], ".")...)
+	}
+	if v.Major == 0 && v.Minor == 0 && v.Patch == 0 && fourPartBuild(v) == 0 {
+		return semver.Version{}, ErrNoTags
+	}
+	return v, nil
+}
+
+// Bump bumps the first three components as semver does, and increments BUILD.
+func (FourPartScheme) Bump(curr semver.Version, releaseType ReleaseType, now time.Time) semver.Version {
+	next := bumpVersion(curr, releaseType)
+	next.Build = []string{strconv.FormatUint(fourPartBuild(curr)+1, 10)}
+	return next
+}
+
+func (FourPartScheme) Initial(now time.Time) semver.Version {
+	return semver.Version{Minor: 1, Build: []string{"0"}}
+}
+
+// withBuild returns v with the BUILD following curr's, for versions that
+// weren't bumped from curr, such as Release-As pins.
+func (FourPartScheme) withBuild(v, curr semver.Version) semver.Version {
+	v.Build = []string{strconv.FormatUint(fourPartBuild(curr)+1, 10)}
+	return v
+}
+
+// fourPartBuild returns the BUILD component of a four-part version, or 0 if
+// it has none.
+func fourPartBuild(v semver.Version) uint64 {
+	if len(v.Build) == 0 {
+		return 0
+	}
+	n, err := strconv.ParseUint(v.Build[0], 10, 64)
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// compareVersions compares the versions' release components, which for the
+// four-part scheme includes BUILD, returning -1, 0, or 1.
+func compareVersions(scheme Scheme, a, b semver.Version) int {
+	a.Pre, b.Pre = nil, nil
+	if c := a.Compare(b); c != 0 {
+		return c
+	}
+	if _, ok := scheme.(FourPartScheme); ok {
+		ab, bb := fourPartBuild(a), fourPartBuild(b)
+		if ab < bb {
+			return -1
+		} else if ab > bb {
+			return 1
+		}
+	}
+	return 0
+}
+
+func mustParseUint(s string) uint64 {
+	n, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		panic(err)
+	}
+	return n
+}
+
+func inStrs(s string, cands []string) bool {
+	for _, cand := range cands {
+		if s == cand {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,392 @@
+package commit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/blang/semver/v4"
+
+	"github.com/jeffrom/tunk/config"
+	"github.com/jeffrom/tunk/model"
+	"github.com/jeffrom/tunk/vcs"
+)
+
+var testNow = time.Date(2024, time.May, 14, 12, 0, 0, 0, time.UTC)
+
+func TestCalverScheme(t *testing.T) {
+	tcs := []struct {
+		format      string
+		version     string
+		expect      string
+		expectBump  string
+		expectFirst string
+	}{
+		{
+			format:      "YYYY.MM.MICRO",
+			version:     "2024.5.2",
+			expect:      "2024.5.2",
+			expectBump:  "2024.5.3",
+			expectFirst: "2024.5.0",
+		},
+		{
+			format:      "YY.0M.N",
+			version:     "24.4.7",
+			expect:      "24.04.7",
+			expectBump:  "24.5.0",
+			expectFirst: "24.5.0",
+		},
+		{
+			format:      "0Y.MM.MICRO",
+			version:     "24.5.0",
+			expect:      "24.5.0",
+			expectBump:  "24.5.1",
+			expectFirst: "24.5.0",
+		},
+		{
+			format:      "YYYY.0W.MICRO",
+			version:     "2024.20.0",
+			expect:      "2024.20.0",
+			expectBump:  "2024.20.1",
+			expectFirst: "2024.20.0",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.format, func(t *testing.T) {
+			s, err := NewCalverScheme(tc.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			v := semver.MustParse(tc.version)
+			formatted := s.Format(v)
+			if formatted != tc.expect {
+				t.Errorf("expected format %q, got %q", tc.expect, formatted)
+			}
+
+			for _, tag := range []string{formatted, "v" + formatted, "cool/v" + formatted, "v" + formatted + "+ci.7"} {
+				parsed, err := s.Extract(tag)
+				if err != nil {
+					t.Fatalf("extract %q: %v", tag, err)
+				}
+				if parsed.NE(v) {
+					t.Errorf("expected %q to extract %s, got %s", tag, v, parsed)
+				}
+			}
+
+			rc, err := s.Extract("v" + formatted + "-rc.3")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(rc.Pre) != 2 || rc.Pre[0].String() != "rc" || rc.Pre[1].VersionNum != 3 {
+				t.Errorf("expected prerelease rc.3, got %v", rc.Pre)
+			}
+
+			if bumped := s.Bump(v, ReleaseMajor, testNow); bumped.NE(semver.MustParse(tc.expectBump)) {
+				t.Errorf("expected bump to %s, got %s", tc.expectBump, bumped)
+			}
+			if first := s.Initial(testNow); first.NE(semver.MustParse(tc.expectFirst)) {
+				t.Errorf("expected initial version %s, got %s", tc.expectFirst, first)
+			}
+		})
+	}
+}
+
+func TestCalverSchemeInvalid(t *testing.T) {
+	for _, format := range []string{"YYYY.MM", "MM.YYYY.MICRO", "YYYY.MM.DD", "YYYY.MM.MICRO.N", ""} {
+		t.Run(format, func(t *testing.T) {
+			if _, err := NewCalverScheme(format); err == nil {
+				t.Fatal("expected invalid format")
+			}
+		})
+	}
+
+	s, err := NewCalverScheme("YYYY.0M.MICRO")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tag := range []string{"v1.2.3", "v2024.13.0", "v2024.05.0-rc", "v2024.5.0"} {
+		if v, err := s.Extract(tag); err == nil {
+			t.Errorf("expected %q to be invalid, got %s", tag, v)
+		}
+	}
+}
+
+func TestAnalyzeCalver(t *testing.T) {
+	tio, _, _ := mockTermIO(nil)
+	tcs := []struct {
+		name          string
+		cfg           *config.Config
+		tags          []string
+		rc            string
+		expectTag     string
+		expectVersion string
+		shouldFail    bool
+	}{
+		{
+			name:      "new-month",
+			tags:      []string{"v2024.4.3"},
+			expectTag: "v2024.5.0",
+		},
+		{
+			name:      "same-month",
+			tags:      []string{"v2024.4.3", "v2024.5.0"},
+			expectTag: "v2024.5.1",
+		},
+		{
+			name:      "rc",
+			tags:      []string{"v2024.5.0", "v2024.5.1-rc.0"},
+			rc:        "rc",
+			expectTag: "v2024.5.1-rc.1",
+		},
+		{
+			name:      "padded",
+			cfg:       &config.Config{CalverFormat: "YY.0M.N"},
+			tags:      []string{"v24.04.3", "v23.12.9"},
+			expectTag: "v24.05.0",
+		},
+		{
+			name:      "override",
+			cfg:       &config.Config{Major: true},
+			tags:      []string{"v2024.5.0"},
+			expectTag: "v2024.5.1",
+		},
+		{
+			name:       "graduate",
+			cfg:        &config.Config{Graduate: true},
+			tags:       []string{"v2024.5.0"},
+			shouldFail: true,
+		},
+		{
+			name:      "major-approval",
+			cfg:       &config.Config{Major: true, RequireMajorApproval: true},
+			tags:      []string{"v2024.5.0"},
+			expectTag: "v2024.5.1",
+		},
+		{
+			name:      "scope",
+			cfg:       &config.Config{Scope: "cool"},
+			tags:      []string{"v2024.5.0", "cool/v2024.3.1"},
+			expectTag: "cool/v2024.5.0",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			overrides := tc.cfg
+			if overrides == nil {
+				overrides = &config.Config{}
+			}
+			overrides.VersionScheme = SchemeCalver
+			cfg := newTestConfig(overrides, &tio)
+			tag, err := NewTagFromConfig(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			m := vcs.NewMock().SetTags(tc.tags...).SetCommits(&model.Commit{ID: "deadbeef", Subject: "chore: cool"}, conventionalPatchCommit)
+			a := NewAnalyzer(cfg, m, tag)
+			a.now = func() time.Time { return testNow }
+
+			vers, err := a.Analyze(context.Background(), tc.rc)
+			if tc.shouldFail {
+				if err == nil {
+					t.Fatalf("expected error, got %v", vers)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(vers) != 1 {
+				t.Fatalf("expected 1 version, got %d", len(vers))
+			}
+			got, err := tag.ExecuteString(TagData{Version: vers[0]})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.expectTag {
+				t.Errorf("expected tag %q, got %q", tc.expectTag, got)
+			}
+		})
+	}
+}
+
+func TestFourPartScheme(t *testing.T) {
+	s := FourPartScheme{}
+	v := semver.Version{Major: 1, Minor: 2, Patch: 3, Build: []string{"4"}}
+	if formatted := s.Format(v); formatted != "1.2.3.4" {
+		t.Errorf("expected format %q, got %q", "1.2.3.4", formatted)
+	}
+
+	for _, tag := range []string{"1.2.3.4", "v1.2.3.4", "cool/v1.2.3.4", "v1.2.3.4+ci.7"} {
+		parsed, err := s.Extract(tag)
+		if err != nil {
+			t.Fatalf("extract %q: %v", tag, err)
+		}
+		if compareVersions(s, parsed, v) != 0 || parsed.Build[0] != "4" {
+			t.Errorf("expected %q to extract %s, got %s", tag, s.Format(v), s.Format(parsed))
+		}
+	}
+	for _, tag := range []string{"v1.2.3", "cool/v1.2.3", "v1.2.3.4.5", "v01.2.3.4", "v0.0.0.0"} {
+		if parsed, err := s.Extract(tag); err == nil {
+			t.Errorf("expected %q to be invalid, got %s", tag, s.Format(parsed))
+		}
+	}
+
+	rc, err := s.Extract("v1.2.3.4-rc.3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rc.Pre) != 2 || rc.Pre[0].String() != "rc" || rc.Pre[1].VersionNum != 3 {
+		t.Errorf("expected prerelease rc.3, got %v", rc.Pre)
+	}
+
+	bumps := map[ReleaseType]string{
+		ReleasePatch: "1.2.4.5",
+		ReleaseMinor: "1.3.0.5",
+		ReleaseMajor: "2.0.0.5",
+	}
+	for rt, expect := range bumps {
+		if bumped := s.Format(s.Bump(v, rt, testNow)); bumped != expect {
+			t.Errorf("expected %s bump to %s, got %s", rt, expect, bumped)
+		}
+	}
+	if first := s.Format(s.Initial(testNow)); first != "0.1.0.0" {
+		t.Errorf("expected initial version 0.1.0.0, got %s", first)
+	}
+}
+
+func TestSemverSchemeFourPartTags(t *testing.T) {
+	for _, tag := range []string{"v1.2.3.4", "1.2.3.4", "cool/v1.2.3.4", "v0.1.2.3-rc.0"} {
+		if v, err := extractSemver(tag); err == nil {
+			t.Errorf("expected %q to be invalid semver, got %s", tag, v)
+		}
+	}
+	for _, tag := range []string{"v1.2.3", "cool/v1.2.3", "cool.thing/v1.2.3", "v1.2.3-rc.0", "v1.2.3+ci.7"} {
+		if v, err := extractSemver(tag); err != nil || v.Major != 1 || v.Minor != 2 || v.Patch != 3 {
+			t.Errorf("expected %q to extract 1.2.3, got %s (err: %v)", tag, v, err)
+		}
+	}
+}
+
+func TestAnalyzeFourPart(t *testing.T) {
+	tio, _, _ := mockTermIO(nil)
+	tcs := []struct {
+		name       string
+		cfg        *config.Config
+		tags       []string
+		commits    []*model.Commit
+		rc         string
+		expectTag  string
+		shouldFail bool
+	}{
+		{
+			name:      "patch",
+			tags:      []string{"v1.2.3.9", "v1.2.3.10", "v1.2.3"},
+			commits:   []*model.Commit{conventionalPatchCommit},
+			expectTag: "v1.2.4.11",
+		},
+		{
+			name:      "minor",
+			tags:      []string{"v1.2.3.10"},
+			commits:   []*model.Commit{conventionalMinorCommit},
+			expectTag: "v1.3.0.11",
+		},
+		{
+			name:      "major",
+			tags:      []string{"v1.2.3.10"},
+			commits:   []*model.Commit{conventionalMajorCommit},
+			expectTag: "v2.0.0.11",
+		},
+		{
+			name:      "rc",
+			tags:      []string{"v1.2.3.10", "v1.2.4.11-rc.0"},
+			commits:   []*model.Commit{conventionalPatchCommit},
+			rc:        "rc",
+			expectTag: "v1.2.4.11-rc.1",
+		},
+		{
+			name:      "override",
+			cfg:       &config.Config{Minor: true},
+			tags:      []string{"v1.2.3.10"},
+			commits:   []*model.Commit{conventionalSkipCommit},
+			expectTag: "v1.3.0.11",
+		},
+		{
+			name:      "release-as",
+			tags:      []string{"v1.2.3.10"},
+			commits:   []*model.Commit{{ID: "deadbeef", Subject: "chore: cool", Body: "Release-As: 3.0.0"}},
+			expectTag: "v3.0.0.11",
+		},
+		{
+			name:       "graduate",
+			cfg:        &config.Config{Graduate: true},
+			tags:       []string{"v0.2.3.10"},
+			commits:    []*model.Commit{conventionalPatchCommit},
+			shouldFail: true,
+		},
+		{
+			name:       "major-unapproved",
+			cfg:        &config.Config{Major: true, RequireMajorApproval: true},
+			tags:       []string{"v2.3.4.5"},
+			commits:    []*model.Commit{conventionalPatchCommit},
+			shouldFail: true,
+		},
+		{
+			name:      "major-allow-major",
+			cfg:       &config.Config{Major: true, RequireMajorApproval: true, AllowMajor: true},
+			tags:      []string{"v2.3.4.5"},
+			commits:   []*model.Commit{conventionalPatchCommit},
+			expectTag: "v3.0.0.6",
+		},
+		{
+			name:      "scope",
+			cfg:       &config.Config{Scope: "cool"},
+			tags:      []string{"v1.2.3.10", "cool/v0.4.0.2"},
+			commits:   []*model.Commit{conventionalScopedPatchCommit},
+			expectTag: "cool/v0.4.1.3",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			overrides := tc.cfg
+			if overrides == nil {
+				overrides = &config.Config{}
+			}
+			overrides.VersionScheme = SchemeFourPart
+			cfg := newTestConfig(overrides, &tio)
+			tag, err := NewTagFromConfig(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			m := vcs.NewMock().SetTags(tc.tags...).SetCommits(tc.commits...)
+			a := NewAnalyzer(cfg, m, tag)
+
+			vers, err := a.Analyze(context.Background(), tc.rc)
+			if tc.shouldFail {
+				if err == nil {
+					t.Fatalf("expected error, got %v", vers)
+				}
+				if tc.cfg.RequireMajorApproval && !errors.Is(err, MajorApprovalError{}) {
+					t.Fatalf("expected major approval error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(vers) != 1 {
+				t.Fatalf("expected 1 version, got %d", len(vers))
+			}
+			got, err := tag.ExecuteString(TagData{Version: vers[0]})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.expectTag {
+				t.Errorf("expected tag %q, got %q", tc.expectTag, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package commit
+
+import (
+	"context"
+	"sort"
+)
+
+// DetectScopes returns the scopes that have release tags, sorted by name. Tags
+// are read in the formats of the tag template and legacy tag templates, so a
+// scope is detected once its first tag is created.
+func (a *Analyzer) DetectScopes(ctx context.Context) ([]string, error) {
+	root := a.root()
+	if root.scopesDetected {
+		return root.detectedScopes, nil
+	}
+	tags, err := root.readTags(ctx, "*", "", root.vcs.ReadTags)
+	if err != nil {
+		return nil, err
+	}
+	var scopes []string
+	for _, tag := range tags {
+		scope, ok := root.tag.ExtractScope(tag)
+		if !ok || inStrs(scope, scopes) {
+			continue
+		}
+		// the scope's own tag template must also read the tag.
+		sa, err := root.ForScope(scope)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := sa.tag.ExtractSemver(scope, "", tag); err != nil {
+			continue
+		}
+		scopes = append(scopes, scope)
+	}
+	sort.Strings(scopes)
+	root.cfg.Debugf("detected scopes: %q", scopes)
+	root.detectedScopes = scopes
+	root.scopesDetected = true
+	return scopes, nil
+}
+
+// releaseScopes returns the configured release scopes, and when operating on
+// all scopes, the scopes detected from tags.
+func (a *Analyzer) releaseScopes() []string {
+	root := a.root()
+	scopes := append([]string(nil), root.cfg.ReleaseScopes...)
+	if !root.cfg.All {
+		return scopes
+	}
+	for _, scope := range root.detectedScopes {
+		if !inStrs(scope, scopes) {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes
+}
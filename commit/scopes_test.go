@@ -0,0 +1,91 @@
+package commit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jeffrom/tunk/config"
+	"github.com/jeffrom/tunk/vcs"
+)
+
+func TestAnalyzerDetectScopes(t *testing.T) {
+	tio, _, _ := mockTermIO(nil)
+	tcs := []struct {
+		name   string
+		cfg    *config.Config
+		tags   []string
+		expect []string
+	}{
+		{
+			name: "none",
+			cfg:  &config.Config{},
+			tags: []string{"v0.1.0", "v0.2.0-rc.0"},
+		},
+		{
+			name:   "default",
+			cfg:    &config.Config{},
+			tags:   []string{"v0.1.0", "nice/v0.1.0", "cool/v0.1.0", "cool/v0.1.1-rc.0", "nice/v0.2.0"},
+			expect: []string{"cool", "nice"},
+		},
+		{
+			name:   "nested",
+			cfg:    &config.Config{},
+			tags:   []string{"pkg/cool/v1.0.0", "pkg/v1.0.0"},
+			expect: []string{"pkg", "pkg/cool"},
+		},
+		{
+			name:   "invalid",
+			cfg:    &config.Config{},
+			tags:   []string{"cool/v1", "nice/release", "very/v1.2.3.4", "ok/v1.0.0"},
+			expect: []string{"ok"},
+		},
+		{
+			name: "custom-template",
+			cfg: &config.Config{TagTemplate: `{{- with $scope := .Version.Scope -}}
+{{- $scope -}}#
+{{- end -}}
+v{{- semver .Version -}}`},
+			tags:   []string{"cool#v0.1.0", "nice/v0.1.0"},
+			expect: []string{"cool"},
+		},
+		{
+			name:   "legacy",
+			cfg:    &config.Config{TagTemplate: `{{- with $scope := .Version.Scope -}}{{- $scope -}}@{{- end -}}{{- semver .Version -}}`, LegacyTagTemplates: []string{DefaultTagTemplate}},
+			tags:   []string{"cool/v0.1.0", "nice@0.1.0"},
+			expect: []string{"cool", "nice"},
+		},
+		{
+			name: "scope-template",
+			cfg: &config.Config{Scopes: map[string]config.ScopeConfig{
+				"cool": {TagTemplate: `cool-{{- semver .Version -}}`},
+			}},
+			tags:   []string{"cool/v0.1.0", "nice/v0.1.0"},
+			expect: []string{"nice"},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := newTestConfig(tc.cfg, &tio)
+			tag, err := NewTagFromConfig(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			m := vcs.NewMock().SetTags(tc.tags...)
+			a := NewAnalyzer(cfg, m, tag)
+
+			scopes, err := a.DetectScopes(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(scopes) != len(tc.expect) {
+				t.Fatalf("expected scopes %q, got %q", tc.expect, scopes)
+			}
+			for i := range scopes {
+				if scopes[i] != tc.expect[i] {
+					t.Errorf("expected scopes %q, got %q", tc.expect, scopes)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package commit
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/blang/semver/v4"
+)
+
+// ParseVersion reads a release version from a string, such as a version or a
+// tag of any template, as tunk reads tags.
+func (t *Tag) ParseVersion(s string) (semver.Version, error) {
+	v, err := t.scheme.Extract(s)
+	if err != nil {
+		return semver.Version{}, fmt.Errorf("invalid version %q", s)
+	}
+	if len(v.Pre) != 0 && !validTunkPre(v.Pre) {
+		return semver.Version{}, fmt.Errorf("invalid version %q: prereleases must look like rc.0", s)
+	}
+	return v, nil
+}
+
+// CompareVersions returns -1, 0, or 1 if a is released before, at the same
+// time as, or after b. Build metadata is ignored.
+func (t *Tag) CompareVersions(a, b semver.Version) int {
+	vers := tunkVersions{versions: []semver.Version{a, b}, channels: t.channels, scheme: t.scheme}
+	if vers.Less(0, 1) {
+		return -1
+	} else if vers.Less(1, 0) {
+		return 1
+	}
+	return 0
+}
+
+// SortVersions sorts versions in release order. Versions that are released
+// at the same time keep their order.
+func (t *Tag) SortVersions(versions []semver.Version) {
+	sort.Stable(tunkVersions{versions: versions, channels: t.channels, scheme: t.scheme})
+}
+
+// BumpVersion returns the release following v. Any prerelease or build
+// metadata is dropped.
+func (t *Tag) BumpVersion(v semver.Version, releaseType ReleaseType, now time.Time) semver.Version {
+	next := t.scheme.Bump(t.Release(v), releaseType, now)
+	next.Pre = nil
+	return next
+}
+
+// BumpPrerelease returns the prerelease following v. If v is a prerelease of
+// the same name, or name is empty, its number is incremented. Otherwise, the
+// prerelease is numbered from 0, on the next patch version if v isn't already
+// a prerelease. Moving to a prerelease that is released before v fails.
+func (t *Tag) BumpPrerelease(v semver.Version, name string, now time.Time) (semver.Version, error) {
+	prev := v
+	if len(v.Pre) == 0 {
+		if name == "" {
+			return semver.Version{}, fmt.Errorf("%s is not a prerelease, so a prerelease name is required", t.scheme.Format(v))
+		}
+		v = t.BumpVersion(v, ReleasePatch, now)
+	} else {
+		pre := v.Pre
+		v = t.Release(v)
+		if name == "" || pre[0].String() == name {
+			v.Pre = []semver.PRVersion{pre[0], {VersionNum: pre[1].VersionNum + 1, IsNum: true}}
+			return v, nil
+		}
+	}
+	if !tunkPreNameRE.MatchString(name) {
+		return semver.Version{}, fmt.Errorf("invalid prerelease name %q", name)
+	}
+	v.Pre = []semver.PRVersion{{VersionStr: name}, {VersionNum: 0, IsNum: true}}
+	if t.CompareVersions(v, prev) <= 0 {
+		return semver.Version{}, fmt.Errorf("prerelease %s is released before %s", t.scheme.Format(v), t.scheme.Format(prev))
+	}
+	return v, nil
+}
+
+// FormatSemver renders the version as the semver template function does.
+func (t *Tag) FormatSemver(v semver.Version) string {
+	return t.FormatVersion(&Version{Version: v})
+}
@@ -0,0 +1,140 @@
+package commit
+
+import (
+	"testing"
+
+	"github.com/blang/semver/v4"
+)
+
+func TestTagBumpVersion(t *testing.T) {
+	tag, err := NewTag("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tcs := []struct {
+		version     string
+		releaseType ReleaseType
+		pre         string
+		expect      string
+		shouldFail  bool
+	}{
+		{version: "v1.2.3", releaseType: ReleaseMajor, expect: "2.0.0"},
+		{version: "v1.2.3", releaseType: ReleaseMinor, expect: "1.3.0"},
+		{version: "v1.2.3+ci.7", releaseType: ReleasePatch, expect: "1.2.4"},
+		{version: "v1.2.3-rc.1", releaseType: ReleasePatch, expect: "1.2.4"},
+		{version: "v1.2.3-rc.1", releaseType: ReleaseSkip, expect: "1.2.3-rc.2"},
+		{version: "v1.2.3-rc.1", releaseType: ReleaseSkip, pre: "rc", expect: "1.2.3-rc.2"},
+		{version: "v1.2.3-alpha.1", releaseType: ReleaseSkip, pre: "beta", expect: "1.2.3-beta.0"},
+		{version: "v1.2.3", releaseType: ReleaseSkip, pre: "rc", expect: "1.2.4-rc.0"},
+		{version: "v1.2.3-rc.3", releaseType: ReleaseSkip, pre: "beta", shouldFail: true},
+		{version: "v1.2.3", releaseType: ReleaseSkip, shouldFail: true},
+		{version: "v1.2.3", releaseType: ReleaseSkip, pre: "r.c", shouldFail: true},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.version+"-"+tc.expect, func(t *testing.T) {
+			v, err := tag.ParseVersion(tc.version)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var next semver.Version
+			if tc.releaseType == ReleaseSkip {
+				next, err = tag.BumpPrerelease(v, tc.pre, testNow)
+			} else {
+				next = tag.BumpVersion(v, tc.releaseType, testNow)
+			}
+			if tc.shouldFail {
+				if err == nil {
+					t.Fatalf("expected error, got %s", next)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := tag.FormatSemver(next); got != tc.expect {
+				t.Errorf("expected %s, got %s", tc.expect, got)
+			}
+			if tag.CompareVersions(next, v) <= 0 {
+				t.Errorf("expected %s to be released after %s", tag.FormatSemver(next), tc.version)
+			}
+		})
+	}
+}
+
+func TestTagBumpPrereleaseChannels(t *testing.T) {
+	tag, err := NewTagWithOpts("", TagOpts{Channels: []string{"rc", "beta"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tcs := []struct {
+		version    string
+		pre        string
+		expect     string
+		shouldFail bool
+	}{
+		{version: "v1.2.3-rc.3", pre: "beta", expect: "1.2.3-beta.0"},
+		{version: "v1.2.3-beta.1", pre: "rc", shouldFail: true},
+		{version: "v1.2.3-beta.1", pre: "beta", expect: "1.2.3-beta.2"},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.version+"-"+tc.pre, func(t *testing.T) {
+			v, err := tag.ParseVersion(tc.version)
+			if err != nil {
+				t.Fatal(err)
+			}
+			next, err := tag.BumpPrerelease(v, tc.pre, testNow)
+			if tc.shouldFail {
+				if err == nil {
+					t.Fatalf("expected error, got %s", next)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := tag.FormatSemver(next); got != tc.expect {
+				t.Errorf("expected %s, got %s", tc.expect, got)
+			}
+			if tag.CompareVersions(next, v) <= 0 {
+				t.Errorf("expected %s to be released after %s", tc.expect, tc.version)
+			}
+		})
+	}
+}
+
+func TestTagCompareVersions(t *testing.T) {
+	tag, err := NewTagWithOpts("", TagOpts{Channels: []string{"canary", "beta", "rc"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tcs := []struct {
+		a, b   string
+		expect int
+	}{
+		{a: "v1.10.0", b: "v1.9.0", expect: 1},
+		{a: "v1.2.3", b: "1.2.3+ci.7", expect: 0},
+		{a: "v1.2.3-rc.0", b: "v1.2.3", expect: -1},
+		{a: "v1.2.3-rc.10", b: "v1.2.3-rc.9", expect: 1},
+		{a: "v1.2.3-canary.4", b: "v1.2.3-beta.0", expect: -1},
+	}
+	for _, tc := range tcs {
+		a, err := tag.ParseVersion(tc.a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := tag.ParseVersion(tc.b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := tag.CompareVersions(a, b); got != tc.expect {
+			t.Errorf("compare %s %s: expected %d, got %d", tc.a, tc.b, tc.expect, got)
+		}
+	}
+
+	for _, s := range []string{"v1.2.3.4", "v1.2.3-rc", "v1.2.3-rc.1.2", "nope"} {
+		if v, err := tag.ParseVersion(s); err == nil {
+			t.Errorf("expected %q to be invalid, got %s", s, v)
+		}
+	}
+}
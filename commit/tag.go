@@ -5,13 +5,17 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"os"
 	"regexp"
 	"sort"
 	"strconv"
 	"strings"
 	"text/template"
+	"text/template/parse"
 
 	"github.com/blang/semver/v4"
+
+	"github.com/jeffrom/tunk/config"
 )
 
 var ErrNoTags = errors.New("commit: no release tags found")
@@ -23,24 +27,79 @@ v{{- semver .Version -}}`
 
 type TagData struct {
 	Version *Version
+	// Commit is the id of the commit being tagged. It defaults to the
+	// version's commit.
+	Commit string
 }
 
 var funcMap = template.FuncMap{
 	"join": strings.Join,
-	"semver": func(v *Version) string {
-		main := v.V()
-		if len(v.Version.Pre) > 0 {
-			return main + "-" + strings.Join(v.Pre(), ".")
+	"env":  os.Getenv,
+	"short": func(id string) string {
+		if len(id) >= 8 {
+			return id[:8]
 		}
-		return main
+		return id
 	},
+	"semver": semverString,
+}
+
+func semverString(v *Version) string {
+	main := v.V()
+	if len(v.Version.Pre) > 0 {
+		return main + "-" + strings.Join(v.Pre(), ".")
+	}
+	return main
 }
 
 type Tag struct {
 	t *template.Template
+	// meta renders the build metadata following the template's last "+", if
+	// it has one. The "+" is left out when the metadata renders empty.
+	meta     *template.Template
+	scheme   Scheme
+	channels []string
+	// parsers caches the tag parser of each scope. A nil parser means the
+	// template can't be parsed exactly.
+	parsers map[string]*tagParser
+	// legacy are previous tag templates, which are only used to read tags.
+	legacy []*Tag
+}
+
+// TagOpts contains options for NewTagWithOpts.
+type TagOpts struct {
+	// Scheme is the versioning scheme. The default is semver.
+	Scheme Scheme
+	// Channels orders prerelease names, such as alpha, beta, rc.
+	Channels []string
+	// Legacy are previous tag templates. Tags are read in their formats as
+	// well, but are only ever created using the current template.
+	Legacy []string
 }
 
 func NewTag(s string) (*Tag, error) {
+	return NewTagWithOpts(s, TagOpts{})
+}
+
+// NewTagFromConfig returns a tag using the configured tag template and version
+// scheme.
+func NewTagFromConfig(cfg config.Config) (*Tag, error) {
+	scheme, err := NewScheme(cfg.VersionScheme, cfg.CalverFormat)
+	if err != nil {
+		return nil, err
+	}
+	return NewTagWithOpts(cfg.TagTemplate, TagOpts{
+		Scheme:   scheme,
+		Channels: cfg.PrereleaseChannels,
+		Legacy:   cfg.LegacyTagTemplates,
+	})
+}
+
+func NewTagWithOpts(s string, opts TagOpts) (*Tag, error) {
+	scheme := opts.Scheme
+	if scheme == nil {
+		scheme = SemverScheme{}
+	}
 	name := ""
 	if s != "" {
 		name = "custom_tag"
@@ -53,26 +112,131 @@ func NewTag(s string) (*Tag, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &Tag{t: t}, nil
+	var meta *template.Template
+	if main, metaTmpl, ok := splitBuildMetadata(tmpl, t); ok {
+		mt, err := template.New(name).Funcs(funcMap).Parse(main)
+		if err != nil {
+			return nil, err
+		}
+		meta, err = template.New(name + "_meta").Funcs(funcMap).Parse(metaTmpl)
+		if err != nil {
+			return nil, err
+		}
+		t = mt
+	}
+	var legacy []*Tag
+	for _, l := range opts.Legacy {
+		lt, err := NewTagWithOpts(l, TagOpts{Scheme: scheme, Channels: opts.Channels})
+		if err != nil {
+			return nil, fmt.Errorf("legacy tag template %q: %w", l, err)
+		}
+		legacy = append(legacy, lt)
+	}
+	return &Tag{t: t, meta: meta, scheme: scheme, channels: opts.Channels, legacy: legacy}, nil
+}
+
+// splitBuildMetadata splits the template source at the "+" that starts its
+// build metadata, which is the last "+" in the template's text following an
+// action, such as in "v{{ semver .Version }}+{{ .Commit | short }}".
+func splitBuildMetadata(src string, t *template.Template) (string, string, bool) {
+	if t.Tree == nil || t.Tree.Root == nil {
+		return "", "", false
+	}
+	nodes := t.Tree.Root.Nodes
+	for i := len(nodes) - 1; i > 0; i-- {
+		text, ok := nodes[i].(*parse.TextNode)
+		if !ok {
+			continue
+		}
+		j := bytes.LastIndexByte(text.Text, '+')
+		if j < 0 {
+			continue
+		}
+		pos := int(text.Pos) + j
+		if pos >= len(src) || src[pos] != '+' {
+			return "", "", false
+		}
+		return src[:pos], src[pos+1:], true
+	}
+	return "", "", false
+}
+
+// Scheme returns the tag's versioning scheme.
+func (t *Tag) Scheme() Scheme { return t.scheme }
+
+// BuildMetadata returns the version's build metadata. The four-part scheme's
+// BUILD component is kept in the first build identifier, so it isn't included.
+func (t *Tag) BuildMetadata(v semver.Version) []string {
+	if _, ok := t.scheme.(FourPartScheme); ok && len(v.Build) > 0 {
+		return v.Build[1:]
+	}
+	return v.Build
+}
+
+// Release returns v without its prerelease or build metadata.
+func (t *Tag) Release(v semver.Version) semver.Version {
+	v.Pre = nil
+	if _, ok := t.scheme.(FourPartScheme); ok && len(v.Build) > 0 {
+		v.Build = v.Build[:1]
+	} else {
+		v.Build = nil
+	}
+	return v
+}
+
+// FormatVersion renders the version, including any prerelease, as the semver
+// template function does.
+func (t *Tag) FormatVersion(ver *Version) string {
+	v := *ver
+	v.scheme = t.scheme
+	return semverString(&v)
 }
 
 func (t *Tag) Execute(w io.Writer, d TagData) error {
-	return t.t.Execute(w, d)
+	if d.Version != nil {
+		v := *d.Version
+		v.scheme = t.scheme
+		d.Version = &v
+		if d.Commit == "" {
+			d.Commit = v.Commit
+		}
+	}
+	if err := t.t.Execute(w, d); err != nil {
+		return err
+	}
+	if t.meta == nil {
+		return nil
+	}
+	// build metadata that renders empty, such as "+{{ .Commit | short }}"
+	// when there is no commit, is left out along with its "+".
+	meta := &bytes.Buffer{}
+	if err := t.meta.Execute(meta, d); err != nil {
+		return err
+	}
+	if meta.Len() == 0 {
+		return nil
+	}
+	if _, err := io.WriteString(w, "+"); err != nil {
+		return err
+	}
+	_, err := meta.WriteTo(w)
+	return err
 }
 
+// ExecuteString renders the tag.
 func (t *Tag) ExecuteString(d TagData) (string, error) {
 	b := &bytes.Buffer{}
 	if err := t.Execute(b, d); err != nil {
 		return "", err
 	}
-
 	return b.String(), nil
 }
 
 func (t *Tag) Glob(scope, rc string) (string, error) {
-	return t.ExecuteString(TagData{
+	glob, err := t.ExecuteString(TagData{
 		Version: &Version{forGlob: true, Scope: scope},
 	})
+	return globBuildMetadata(glob), err
 }
 
 func (t *Tag) GlobVersion(scope, rc string, v semver.Version) (string, error) {
@@ -82,9 +246,36 @@ func (t *Tag) GlobVersion(scope, rc string, v semver.Version) (string, error) {
 			{VersionStr: "*"},
 		}
 	}
-	return t.ExecuteString(TagData{
+	glob, err := t.ExecuteString(TagData{
 		Version: &Version{forGlob: true, Scope: scope, Version: v},
 	})
+	return globBuildMetadata(glob), err
+}
+
+// globBuildMetadata replaces build metadata following the glob's last wildcard
+// with a wildcard, so tags match regardless of their metadata.
+func globBuildMetadata(glob string) string {
+	i := strings.LastIndex(glob, "*")
+	if i < 0 {
+		return glob
+	}
+	j := strings.Index(glob[i:], "+")
+	if j < 0 {
+		return glob
+	}
+	glob = glob[:i+j]
+	if !strings.HasSuffix(glob, "*") {
+		glob += "*"
+	}
+	return glob
+}
+
+// Alias renders the alias tag of a version line, such as v1 or v1.4, which is
+// the tag with the version replaced by its first components.
+func (t *Tag) Alias(scope, line string) (string, error) {
+	return t.ExecuteString(TagData{
+		Version: &Version{alias: line, Scope: scope},
+	})
 }
 
 func (t *Tag) Prefix(scope string) (string, error) {
@@ -93,20 +284,119 @@ func (t *Tag) Prefix(scope string) (string, error) {
 	})
 }
 
+// ExtractSemver reads the version from a tag of the scope. Tags are parsed
+// according to the template, or failing that, the legacy templates, so tags of
+// other templates or scopes are invalid. If a template can't be parsed, such
+// as when it renders the version's fields separately, the version found after
+// the scope's prefix is used.
 func (t *Tag) ExtractSemver(scope, rc, tag string) (semver.Version, error) {
-	// TODO populate TagData to render a regex that matches tags "better"?
-	return extractSemver(tag)
+	v, err := t.extractSemver(scope, tag)
+	if err != nil {
+		for _, l := range t.legacy {
+			if lv, lerr := l.extractSemver(scope, tag); lerr == nil {
+				return lv, nil
+			}
+		}
+	}
+	return v, err
+}
+
+func (t *Tag) extractSemver(scope, tag string) (semver.Version, error) {
+	if p := t.parser(scope); p != nil {
+		return p.parse(t.scheme, tag)
+	}
+	prefix, err := t.fallbackPrefix(scope)
+	if err != nil {
+		return semver.Version{}, err
+	}
+	rest := strings.TrimPrefix(tag, prefix)
+	if (prefix != "" && rest == tag) || rest == "" || rest[0] < '0' || rest[0] > '9' {
+		return semver.Version{}, errInvalidSemver
+	}
+	return t.scheme.Extract(rest)
+}
+
+// fallbackPrefix returns what the scope's tags begin with when the template
+// can't be parsed: the rendered tag up to the first digit following the
+// scope. The version must follow it, so tags of other scopes aren't read.
+func (t *Tag) fallbackPrefix(scope string) (string, error) {
+	rendered, err := t.ExecuteString(TagData{Version: &Version{Scope: scope}})
+	if err != nil {
+		return "", err
+	}
+	start := 0
+	if scope != "" {
+		if i := strings.Index(rendered, scope); i >= 0 {
+			start = i + len(scope)
+		}
+	}
+	if i := strings.IndexAny(rendered[start:], "0123456789"); i >= 0 {
+		return rendered[:start+i], nil
+	}
+	return rendered, nil
+}
+
+// Globs returns the glob queries for the scope's tags, in the current template
+// and then the legacy templates.
+func (t *Tag) Globs(scope, rc string) ([]string, error) {
+	var globs []string
+	for _, tt := range append([]*Tag{t}, t.legacy...) {
+		glob, err := tt.Glob(scope, rc)
+		if err != nil {
+			return nil, err
+		}
+		if !inStrs(glob, globs) {
+			globs = append(globs, glob)
+		}
+	}
+	return globs, nil
+}
+
+// ExtractScope reads the scope from a tag in the format of the template, or
+// the legacy templates. It returns false for tags without a scope, and tags
+// that can't be parsed.
+func (t *Tag) ExtractScope(tag string) (string, bool) {
+	for _, tt := range append([]*Tag{t}, t.legacy...) {
+		if p := tt.parser(scopeSentinel); p != nil {
+			if scope, ok := p.scope(tt.scheme, tag); ok {
+				return scope, true
+			}
+		}
+	}
+	return "", false
+}
+
+// HasLegacy returns true if the tag has legacy templates.
+func (t *Tag) HasLegacy() bool { return len(t.legacy) > 0 }
+
+func (t *Tag) parser(scope string) *tagParser {
+	if t.parsers == nil {
+		t.parsers = make(map[string]*tagParser)
+	}
+	p, ok := t.parsers[scope]
+	if !ok {
+		p = t.newTagParser(scope)
+		t.parsers[scope] = p
+	}
+	return p
 }
 
 func (t *Tag) SemverLatest(tags []string, scope, rc string) (semver.Version, error) {
+	_, v, err := t.Latest(tags, scope, rc)
+	return v, err
+}
+
+// Latest returns the latest release tag, and its version, from tags.
+func (t *Tag) Latest(tags []string, scope, rc string) (string, semver.Version, error) {
 	var versions []semver.Version
+	versionTags := make(map[string]string)
 	for _, tag := range tags {
 		v, err := t.ExtractSemver(scope, rc, tag)
 		if err != nil {
 			if errors.Is(err, errInvalidSemver) {
 				continue
 			}
-			return semver.Version{}, err
+			return "", semver.Version{}, err
 		}
 
 		if rc == "" && len(v.Pre) != 0 {
@@ -119,15 +409,19 @@ func (t *Tag) SemverLatest(tags []string, scope, rc string) (semver.Version, err
 			continue
 		}
 
+		if _, ok := versionTags[v.String()]; !ok {
+			versionTags[v.String()] = tag
+		}
 		versions = append(versions, v)
 	}
 
-	sort.Sort(tunkVersions(versions))
+	sort.Sort(tunkVersions{versions: versions, channels: t.channels, scheme: t.scheme})
 	// fmt.Println("sorted tags:", versions)
 	if len(versions) > 0 {
-		return versions[len(versions)-1], nil
+		latest := versions[len(versions)-1]
+		return versionTags[latest.String()], latest, nil
 	}
-	return semver.Version{}, ErrNoTags
+	return "", semver.Version{}, ErrNoTags
 }
 
 var tunkPreNameRE = regexp.MustCompile(`^[A-Za-z\d]+$`)
@@ -153,7 +447,8 @@ func validTunkPre(pre []semver.PRVersion) bool {
 }
 
 // semverRE is the official semver regexp with a slight tweak (to disallow
-// extra zeros at the cost of losing buildmetadata):
+// extra zeros at the cost of losing buildmetadata, which extractSemverRE reads
+// separately):
 // https://semver.org/#is-there-a-suggested-regular-expression-regex-to-check-a-semver-string
 var semverRE = regexp.MustCompile(`(?P<major>0|[1-9]\d*)\.(?P<minor>0|[1-9]\d*)\.(?P<patch>0|[1-9]\d*)(?:-(?P<prerelease>(?:0$|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*)(?:\.(?:0$|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*))*))?(?:\+(?P<buildmetadata>[0-9a-zA-Z-]+(?:\.[0-9a-zA-Z-]+)*))?`)
 
@@ -172,15 +467,6 @@ Loop:
 				break
 			}
 
-			// TODO "vanity version number" ie, v1.2.3.4, doesn't work. The
-			// commented out code below detects it, but also causes scoped tags
-			// to fail validation.
-			// if parts := strings.SplitN(part, "-", 2); len(parts) > 0 {
-			// 	if mainParts := strings.Split(parts[0], "."); len(mainParts) != 3 {
-			// 		return v, errInvalidSemver
-			// 	}
-			// }
-
 			var err error
 			v, err = semver.ParseTolerant(trimLeadingZeroRE.ReplaceAllLiteralString(part, "0"))
 			if err != nil {
@@ -188,10 +474,19 @@ Loop:
 				continue
 			}
 
+			// a longer dotted version, such as v1.2.3.4, isn't semver.
+			if isDottedVersionPart(s, start, start+size) {
+				return semver.Version{}, errInvalidSemver
+			}
+
 			// if its a valid semver but still invalid tunk release tag, bail
-			if _, verr := extractSemverRE(part); verr != nil {
+			rev, verr := extractSemverRE(part)
+			if verr != nil {
 				return v, verr
 			}
+			// ParseTolerant was given the part with leading zeros trimmed,
+			// which can also change the numbers, so use the strict parse.
+			v = rev
 
 			lastErr = nil
 			break Loop
@@ -201,9 +496,33 @@ Loop:
 	return v, lastErr
 }
 
+// isDottedVersionPart returns true if s[start:end] is part of a version with
+// more than three components, such as 1.2.3 in v1.2.3.4 or v0.1.2.3.
+func isDottedVersionPart(s string, start, end int) bool {
+	isDigit := func(i int) bool { return i >= 0 && i < len(s) && s[i] >= '0' && s[i] <= '9' }
+	main := end
+	if i := strings.IndexAny(s[start:end], "-+"); i >= 0 {
+		main = start + i
+	}
+	if main == end && end < len(s) && s[end] == '.' && isDigit(end+1) {
+		return true
+	}
+	return start > 0 && s[start-1] == '.' && isDigit(start-2)
+}
+
 func extractSemverRE(s string) (semver.Version, error) {
+	var build []string
+	if i := strings.Index(s, "+"); i >= 0 {
+		for _, part := range strings.Split(s[i+1:], ".") {
+			if _, err := semver.NewBuildVersion(part); err != nil {
+				return semver.Version{}, errInvalidSemver
+			}
+			build = append(build, part)
+		}
+		s = s[:i]
+	}
 	if !semverRE.MatchString(s) {
-		return semver.Version{}, fmt.Errorf("failed to parse semver from string: %q", s)
+		return semver.Version{}, fmt.Errorf("%w: failed to parse semver from string: %q", errInvalidSemver, s)
 	}
 	m := semverRE.FindAllStringSubmatch(s, 1)
 
@@ -259,8 +578,8 @@ func extractSemverRE(s string) (semver.Version, error) {
 			}
 			v.Pre = pres
 		}
-		// TODO case "buildmeta"
 	}
+	v.Build = build
 
 	if err := v.Validate(); err != nil {
 		return semver.Version{}, err
@@ -271,23 +590,33 @@ func extractSemverRE(s string) (semver.Version, error) {
 	return v, nil
 }
 
-type tunkVersions []semver.Version
+// tunkVersions sorts versions. Prereleases named in channels are ordered by
+// their position in it, other names alphabetically.
+type tunkVersions struct {
+	versions []semver.Version
+	channels []string
+	scheme   Scheme
+}
 
-func (s tunkVersions) Len() int { return len(s) }
+func (s tunkVersions) Len() int { return len(s.versions) }
 func (s tunkVersions) Swap(i, j int) {
-	s[i], s[j] = s[j], s[i]
+	s.versions[i], s.versions[j] = s.versions[j], s.versions[i]
 }
 
 // Less implements sort.Interface. It takes into account tunks rc tag structure
 // (1.2.3-myrc.N)
 func (s tunkVersions) Less(i, j int) bool {
-	a, b := s[i], s[j]
-	if a.Major != b.Major || a.Minor != b.Minor || a.Patch != b.Patch {
-		return a.LT(b)
+	a, b := s.versions[i], s.versions[j]
+	if c := compareVersions(s.scheme, a, b); c != 0 {
+		return c < 0
 	}
 
 	if len(a.Pre) == 2 && len(b.Pre) == 2 {
 		if a.Pre[0] != b.Pre[0] {
+			ai, bi := s.channel(a.Pre[0].String()), s.channel(b.Pre[0].String())
+			if ai >= 0 && bi >= 0 {
+				return ai < bi
+			}
 			return a.LT(b)
 		}
 
@@ -299,3 +628,12 @@ func (s tunkVersions) Less(i, j int) bool {
 	}
 	return a.LT(b)
 }
+
+func (s tunkVersions) channel(name string) int {
+	for i, ch := range s.channels {
+		if ch == name {
+			return i
+		}
+	}
+	return -1
+}
@@ -1,6 +1,8 @@
 package commit
 
 import (
+	"sort"
+	"strings"
 	"testing"
 
 	"github.com/blang/semver/v4"
@@ -16,6 +18,7 @@ func TestTags(t *testing.T) {
 		expectGlob string
 		semver     string
 		scope      string
+		commit     string
 	}{
 		{
 			name:       "default",
@@ -46,6 +49,47 @@ func TestTags(t *testing.T) {
 			expect:     "cool-v1.2.3",
 			expectGlob: "cool-v*",
 		},
+		{
+			name:       "build-commit",
+			tmpl:       `v{{ semver .Version }}+{{ .Commit | short }}`,
+			commit:     "deadbeefcafe",
+			expect:     "v1.2.3+deadbeef",
+			expectGlob: "v*",
+		},
+		{
+			name:       "build-empty",
+			tmpl:       `v{{ semver .Version }}+{{ .Commit | short }}`,
+			expect:     "v1.2.3",
+			expectGlob: "v*",
+		},
+		{
+			name:       "build-scope",
+			tmpl:       `{{ .Version.Scope }}-v{{ semver .Version }}+{{ .Commit | short }}`,
+			semver:     "1.2.3+abc",
+			scope:      "cool",
+			commit:     "deadbeef",
+			expect:     "cool-v1.2.3+deadbeef",
+			expectGlob: "cool-v*",
+		},
+		{
+			name:       "build-trim",
+			tmpl:       `v{{ semver .Version }}+{{- .Commit | short -}}`,
+			expect:     "v1.2.3",
+			expectGlob: "v*",
+		},
+		{
+			name:   "trailing-plus",
+			tmpl:   `v{{ semver .Version }}-{{ .Version.Scope }}`,
+			scope:  "c++",
+			expect: "v1.2.3-c++",
+		},
+		{
+			name:       "build-conditional",
+			tmpl:       `v{{ semver .Version }}{{ with .Commit }}+{{ short . }}{{ end }}`,
+			commit:     "deadbeefcafe",
+			expect:     "v1.2.3+deadbeef",
+			expectGlob: "v*",
+		},
 	}
 
 	for _, tc := range tcs {
@@ -60,7 +104,7 @@ func TestTags(t *testing.T) {
 				sv = semver.MustParse(tc.semver)
 			}
 
-			s, err := tag.ExecuteString(TagData{Version: &Version{Version: sv, Scope: tc.scope}})
+			s, err := tag.ExecuteString(TagData{Version: &Version{Version: sv, Scope: tc.scope, Commit: tc.commit}})
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -81,3 +125,184 @@ func TestTags(t *testing.T) {
 		})
 	}
 }
+
+func TestTagsBuildMetadata(t *testing.T) {
+	tag, err := NewTag("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tags := []string{"v1.2.3+ci.100", "v1.100.0+0042", "v1.100.0-rc.0+abc", "v1.10.0", "v1.2.4+bad+meta"}
+
+	latestTag, latest, err := tag.Latest(tags, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latestTag != "v1.100.0+0042" {
+		t.Errorf("expected latest tag %q, got %q", "v1.100.0+0042", latestTag)
+	}
+	if expect := semver.MustParse("1.100.0+0042"); latest.String() != expect.String() {
+		t.Errorf("expected latest version %s, got %s", expect, latest)
+	}
+
+	rcTag, rc, err := tag.Latest([]string{"v1.2.3+ci.100", "v1.100.0-rc.0+abc"}, "", "rc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rcTag != "v1.100.0-rc.0+abc" || len(rc.Build) != 1 {
+		t.Errorf("expected latest rc tag %q, got %q (%s)", "v1.100.0-rc.0+abc", rcTag, rc)
+	}
+
+	glob, err := tag.GlobVersion("", "rc", latest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if glob != "v1.100.0-rc.*" {
+		t.Errorf("expected glob %q, got %q", "v1.100.0-rc.*", glob)
+	}
+}
+
+func TestTagsPrereleaseChannels(t *testing.T) {
+	tag, err := NewTagWithOpts("", TagOpts{Channels: []string{"canary", "beta", "rc"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tags := []string{"v1.0.0-beta.0", "v1.0.0-canary.3", "v0.9.0", "v1.0.0-canary.10"}
+
+	latestTag, _, err := tag.Latest(tags, "", "beta")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latestTag != "v1.0.0-beta.0" {
+		t.Errorf("expected latest tag %q, got %q", "v1.0.0-beta.0", latestTag)
+	}
+
+	vers := tunkVersions{channels: tag.channels}
+	for _, s := range append(tags, "v1.0.0-rc.0", "v1.0.0-zeta.0") {
+		vers.versions = append(vers.versions, semver.MustParse(s[1:]))
+	}
+	sort.Sort(vers)
+	var got []string
+	for _, v := range vers.versions {
+		got = append(got, v.String())
+	}
+	expect := []string{"0.9.0", "1.0.0-canary.3", "1.0.0-canary.10", "1.0.0-beta.0", "1.0.0-rc.0", "1.0.0-zeta.0"}
+	if strings.Join(got, " ") != strings.Join(expect, " ") {
+		t.Errorf("expected order %q, got %q", expect, got)
+	}
+}
+
+func TestTagsExtractSemver(t *testing.T) {
+	tcs := []struct {
+		name    string
+		tmpl    string
+		scope   string
+		tag     string
+		expect  string
+		invalid bool
+	}{
+		{name: "default", tag: "v1.2.3", expect: "1.2.3"},
+		{name: "default-rc", tag: "v1.2.3-rc.4", expect: "1.2.3-rc.4"},
+		{name: "default-build", tag: "v1.2.3+ci.7", expect: "1.2.3+ci.7"},
+		{name: "default-scope", scope: "cool", tag: "cool/v1.2.3", expect: "1.2.3"},
+		{name: "default-other-scope", tag: "cool/v1.2.3", invalid: true},
+		{name: "default-wrong-scope", scope: "cool", tag: "other/v1.2.3", invalid: true},
+		{name: "default-no-v", tag: "1.2.3", invalid: true},
+		{name: "scope-digits", scope: "v2", tag: "v2/v1.2.3", expect: "1.2.3"},
+		{name: "scope-digits-root", tag: "v2/v1.2.3", invalid: true},
+		{name: "leading-zero", tag: "v01.2.3", invalid: true},
+		{name: "four-parts", tag: "v1.2.3.4", invalid: true},
+		{
+			name:   "date-prefix",
+			tmpl:   `release-2024-{{ semver .Version }}`,
+			tag:    "release-2024-1.2.3",
+			expect: "1.2.3",
+		},
+		{
+			name:    "date-prefix-other-template",
+			tmpl:    `release-2024-{{ semver .Version }}`,
+			tag:     "v1.2.3",
+			invalid: true,
+		},
+		{
+			name:   "pre-join",
+			tmpl:   `v{{ .Version }}{{ with $pre := .Version.Pre }}-{{ join $pre "." }}{{ end }}`,
+			tag:    "v1.2.3-beta.2",
+			expect: "1.2.3-beta.2",
+		},
+		{
+			name:   "build-commit",
+			tmpl:   `{{ .Version.Scope }}-v{{ semver .Version }}+{{ .Commit | short }}`,
+			scope:  "cool",
+			tag:    "cool-v1.2.3+deadbeef",
+			expect: "1.2.3+deadbeef",
+		},
+		{
+			name:   "fields-fallback",
+			tmpl:   `v{{ .Version.Major }}.{{ .Version.Minor }}.{{ .Version.Patch }}`,
+			tag:    "v1.2.3",
+			expect: "1.2.3",
+		},
+		{
+			name:    "fields-fallback-other-scope",
+			tmpl:    `v{{ .Version.Major }}.{{ .Version.Minor }}.{{ .Version.Patch }}`,
+			tag:     "foo/v1.2.3",
+			invalid: true,
+		},
+		{
+			name:   "fields-fallback-scope",
+			tmpl:   `{{ with .Version.Scope }}{{ . }}/{{ end }}v{{ .Version.Major }}.{{ .Version.Minor }}.{{ .Version.Patch }}`,
+			scope:  "foo",
+			tag:    "foo/v1.2.3",
+			expect: "1.2.3",
+		},
+		{
+			name:    "fields-fallback-root-scoped-tag",
+			tmpl:    `{{ with .Version.Scope }}{{ . }}/{{ end }}v{{ .Version.Major }}.{{ .Version.Minor }}.{{ .Version.Patch }}`,
+			tag:     "foo/v1.2.3",
+			invalid: true,
+		},
+		{
+			name:    "fields-fallback-wrong-scope",
+			tmpl:    `{{ with .Version.Scope }}{{ . }}/{{ end }}v{{ .Version.Major }}.{{ .Version.Minor }}.{{ .Version.Patch }}`,
+			scope:   "foo",
+			tag:     "foo/bar/v1.2.3",
+			invalid: true,
+		},
+		{
+			name:    "fields-fallback-no-prefix",
+			tmpl:    `{{ with .Version.Scope }}{{ . }}-{{ end }}{{ .Version.Major }}.{{ .Version.Minor }}.{{ .Version.Patch }}`,
+			tag:     "foo-1.2.3",
+			invalid: true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			tag, err := NewTag(tc.tmpl)
+			if err != nil {
+				t.Fatal(err)
+			}
+			v, err := tag.ExtractSemver(tc.scope, "", tc.tag)
+			if tc.invalid {
+				if err == nil {
+					t.Fatalf("expected %q to be invalid, got %s", tc.tag, v)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if v.String() != tc.expect {
+				t.Fatalf("expected %s, got %s", tc.expect, v)
+			}
+
+			rendered, err := tag.ExecuteString(TagData{Version: &Version{Version: v, Scope: tc.scope}, Commit: "deadbeef"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(tc.tag, rendered) {
+				t.Errorf("expected %s to render %q, got %q", v, tc.tag, rendered)
+			}
+		})
+	}
+}
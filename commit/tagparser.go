@@ -0,0 +1,150 @@
+package commit
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/blang/semver/v4"
+)
+
+// Sentinels are rendered in place of the template data, so the rendered tag
+// shows where each part of a tag comes from.
+const (
+	versionSentinel = "\x00v\x00"
+	preSentinel     = "\x00p\x00"
+	preNumSentinel  = "\x00n\x00"
+	commitSentinel  = "\x00c\x00"
+	scopeSentinel   = "\x00s\x00"
+)
+
+var sentinelREs = map[string]string{
+	versionSentinel: `(?P<version>\d+(?:\.\d+)*)`,
+	preSentinel:     `(?P<pre>[A-Za-z\d]*[A-Za-z][A-Za-z\d]*)`,
+	preNumSentinel:  `(?P<num>0|[1-9]\d*)`,
+	commitSentinel:  `[0-9a-f]*`,
+	scopeSentinel:   `(?P<scope>.+?)`,
+}
+
+const buildMetadataRE = `(?:\+(?P<meta>[0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*))?`
+
+// tagParser reads versions from tags rendered by a tag template for one
+// scope. It matches the whole tag, so tags of other templates and scopes
+// aren't read.
+type tagParser struct {
+	res []*regexp.Regexp
+}
+
+// newTagParser derives a parser from the template. It returns nil if the
+// template doesn't render the version in a way that can be read back, such as
+// when it uses .Version.Major directly.
+func (t *Tag) newTagParser(scope string) *tagParser {
+	p := &tagParser{}
+	for _, pre := range [][]semver.PRVersion{
+		nil,
+		{{VersionStr: preSentinel}, {VersionStr: preNumSentinel}},
+	} {
+		rendered, err := t.ExecuteString(TagData{
+			Version: &Version{forParse: true, Scope: scope, Commit: commitSentinel, Version: semver.Version{Pre: pre}},
+		})
+		if err != nil {
+			return nil
+		}
+		if pre != nil && (strings.Count(rendered, preSentinel) != 1 || strings.Count(rendered, preNumSentinel) != 1) {
+			return nil
+		}
+		re := tagParserRE(rendered)
+		if re == nil {
+			return nil
+		}
+		p.res = append(p.res, re)
+	}
+	return p
+}
+
+// tagParserRE compiles a rendered tag into a regexp. Anything following a "+"
+// after the version is build metadata, which is matched regardless of its
+// value, as is build metadata following the tag.
+func tagParserRE(rendered string) *regexp.Regexp {
+	if strings.Count(rendered, versionSentinel) != 1 || strings.Count(rendered, scopeSentinel) > 1 {
+		return nil
+	}
+	body := rendered
+	end := strings.Index(body, versionSentinel) + len(versionSentinel)
+	if i := strings.Index(body, preNumSentinel); i >= end {
+		end = i + len(preNumSentinel)
+	}
+	if i := strings.Index(body[end:], "+"); i >= 0 {
+		body = body[:end+i]
+	}
+
+	var b strings.Builder
+	b.WriteString("^")
+	for body != "" {
+		i, sentinel := nextSentinel(body)
+		if i < 0 {
+			b.WriteString(regexp.QuoteMeta(body))
+			break
+		}
+		b.WriteString(regexp.QuoteMeta(body[:i]))
+		b.WriteString(sentinelREs[sentinel])
+		body = body[i+len(sentinel):]
+	}
+	b.WriteString(buildMetadataRE + "$")
+	return regexp.MustCompile(b.String())
+}
+
+func nextSentinel(s string) (int, string) {
+	idx, sentinel := -1, ""
+	for cand := range sentinelREs {
+		if i := strings.Index(s, cand); i >= 0 && (idx < 0 || i < idx) {
+			idx, sentinel = i, cand
+		}
+	}
+	return idx, sentinel
+}
+
+// parse reads the version from tag. The version must be formatted by the
+// scheme exactly as it appears in the tag.
+func (p *tagParser) parse(scheme Scheme, tag string) (semver.Version, error) {
+	for _, re := range p.res {
+		m := re.FindStringSubmatch(tag)
+		if m == nil {
+			continue
+		}
+		main := m[re.SubexpIndex("version")]
+		s := main
+		if i := re.SubexpIndex("pre"); i >= 0 {
+			s += "-" + m[i] + "." + m[re.SubexpIndex("num")]
+		}
+		if meta := m[re.SubexpIndex("meta")]; meta != "" {
+			s += "+" + meta
+		}
+
+		v, err := scheme.Extract(s)
+		if err != nil {
+			return v, err
+		}
+		if scheme.Format(v) != main {
+			return semver.Version{}, errInvalidSemver
+		}
+		return v, nil
+	}
+	return semver.Version{}, errInvalidSemver
+}
+
+// scope reads the scope from tag. The parser must be derived with the scope
+// sentinel in place of the scope.
+func (p *tagParser) scope(scheme Scheme, tag string) (string, bool) {
+	if _, err := p.parse(scheme, tag); err != nil {
+		return "", false
+	}
+	for _, re := range p.res {
+		if m := re.FindStringSubmatch(tag); m != nil {
+			if i := re.SubexpIndex("scope"); i >= 0 && m[i] != "" {
+				return m[i], true
+			}
+			return "", false
+		}
+	}
+	return "", false
+}
@@ -1,6 +1,8 @@
 package commit
 
 import (
+	"time"
+
 	"github.com/blang/semver/v4"
 )
 
@@ -10,8 +12,14 @@ type Version struct {
 	AllCommits []*AnalyzedCommit `json:"all_commits"`
 	Commit     string            `json:"commit"`
 	RC         string
-	forGlob    bool
-	forPrefix  bool
+	// Dependencies are the releases of the scope's dependencies that are
+	// released along with it.
+	Dependencies []*Version `json:"dependencies,omitempty"`
+	forGlob      bool
+	forPrefix    bool
+	forParse     bool
+	alias        string
+	scheme       Scheme
 }
 
 func (v *Version) String() string { return v.V() }
@@ -19,9 +27,7 @@ func (v *Version) String() string { return v.V() }
 func (v *Version) V() string {
 	if v.forGlob {
 		if v.Version.GT(semver.Version{}) && len(v.Version.Pre) == 2 {
-			globVer := v.Version
-			globVer.Pre = nil
-			return globVer.String()
+			return v.getScheme().Format(v.Version)
 		}
 		return "*"
 		// return "*.*.*"
@@ -29,10 +35,20 @@ func (v *Version) V() string {
 	if v.forPrefix {
 		return ""
 	}
-	ver := v.Version
-	ver.Pre = nil
-	res := ver.String()
-	return res
+	if v.forParse {
+		return versionSentinel
+	}
+	if v.alias != "" {
+		return v.alias
+	}
+	return v.getScheme().Format(v.Version)
+}
+
+func (v *Version) getScheme() Scheme {
+	if v.scheme == nil {
+		return SemverScheme{}
+	}
+	return v.scheme
 }
 
 func (v *Version) Pre() []string {
@@ -54,10 +70,39 @@ func (v *Version) ScopedCommits() []*AnalyzedCommit {
 	scope := v.Scope
 	var acs []*AnalyzedCommit
 	for _, ac := range v.AllCommits {
-		if ac.Scope != scope {
+		if !ac.HasScope(scope) {
 			continue
 		}
 		acs = append(acs, ac)
 	}
 	return acs
 }
+
+// commitDate returns the date of the version's commit, or if none of its
+// commits are the version's commit, the date of its latest dependency's.
+func (v *Version) commitDate() time.Time {
+	for _, ac := range v.AllCommits {
+		if ac.Commit.ID == v.Commit {
+			return ac.Commit.CommitterDate
+		}
+	}
+	var latest time.Time
+	for _, dep := range v.Dependencies {
+		if d := dep.commitDate(); d.After(latest) {
+			latest = d
+		}
+	}
+	return latest
+}
+
+// latestCommit returns the latest commit of the versions.
+func latestCommit(vers []*Version) string {
+	var commit string
+	var latest time.Time
+	for _, v := range vers {
+		if d := v.commitDate(); commit == "" || d.After(latest) {
+			commit, latest = v.Commit, d
+		}
+	}
+	return commit
+}
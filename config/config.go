@@ -5,6 +5,10 @@ package config
 import (
 	"errors"
 	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
 
 	"github.com/imdario/mergo"
 )
@@ -20,17 +24,57 @@ type Config struct {
 	Major          bool       `json:"major,omitempty"`
 	Minor          bool       `json:"minor,omitempty"`
 	Patch          bool       `json:"patch,omitempty"`
+	Graduate       bool       `json:"graduate,omitempty"`
+	ZeroMajor      bool       `json:"zero_major,omitempty"`
+	AllowMajor     bool       `json:"allow_major,omitempty"`
 	Branches       []string   `json:"branches,omitempty"`
 	ReleaseScopes  []string   `json:"release_scopes,omitempty"`
 	Policies       []string   `json:"policies,omitempty"`
 	CustomPolicies []Policy   `json:"custom_policies,omitempty"`
+	Rules          []Rule     `json:"rules,omitempty"`
 	TagTemplate    string     `json:"tag_template,omitempty"`
 	LogTemplate    string     `json:"log_template,omitempty"`
+	VersionScheme  string     `json:"version_scheme,omitempty"`
+	CalverFormat   string     `json:"calver_format,omitempty"`
 	NoEdit         bool       `json:"no_edit,omitempty"`
 	AllowedScopes  []string   `json:"allowed_scopes,omitempty"`
 	AllowedTypes   []string   `json:"allowed_types,omitempty"`
 	Term           TerminalIO `json:"-"`
 
+	// Scopes contains configuration overrides by scope name.
+	Scopes map[string]ScopeConfig `json:"scopes,omitempty"`
+	// RequireMajorApproval refuses to release major versions decided by
+	// policies unless AllowMajor or Major is also set.
+	RequireMajorApproval bool `json:"require_major_approval,omitempty"`
+	// Ignore excludes commits matching any of the conditions from analysis.
+	Ignore []Condition `json:"ignore,omitempty"`
+	// Lint contains commit message lint rules by name.
+	Lint map[string]LintRule `json:"lint,omitempty"`
+	// PrereleaseChannels lists the allowed prerelease names in release order,
+	// such as alpha, beta, rc.
+	PrereleaseChannels []string `json:"prerelease_channels,omitempty"`
+	// MaintenanceBranches are patterns of branches, such as release/*, that
+	// release within the major or minor version line in their name.
+	MaintenanceBranches []string `json:"maintenance_branches,omitempty"`
+	// VersionFiles are updated with the version and committed before the
+	// release is tagged.
+	VersionFiles []VersionFile `json:"version_files,omitempty"`
+	// AliasTags are the moving alias tags, major and minor, updated to point
+	// at each release, such as v1 and v1.4 for v1.4.2.
+	AliasTags []string `json:"alias_tags,omitempty"`
+	// LegacyTagTemplates are previous tag templates. Tags in their formats are
+	// read as releases, but new tags always use TagTemplate.
+	LegacyTagTemplates []string `json:"legacy_tag_templates,omitempty"`
+	// ScopeSeparator separates the scopes of commits that belong to several
+	// scopes, such as "feat(api,cli): ...".
+	ScopeSeparator string `json:"scope_separator,omitempty"`
+	// VersionGroups are groups of scopes, by name, that always share a
+	// version. They're released together.
+	VersionGroups map[string][]string `json:"version_groups,omitempty"`
+	// NoDetectScopes disables detecting scopes from tags. Otherwise, scopes
+	// with release tags are released with --all, and allowed by check.
+	NoDetectScopes bool `json:"no_detect_scopes,omitempty"`
+
 	// IgnorePolicies ignores policy restrictions. Intended for testing only.
 	IgnorePolicies bool `json:"-"`
 	BranchesSet    bool `json:"-"`
@@ -58,13 +102,165 @@ func NewWithTerminalIO(overrides *Config, termio *TerminalIO) Config {
 }
 
 func (c Config) Validate() error {
-	if (c.Major && (c.Minor || c.Patch)) ||
-		(c.Minor && (c.Patch)) {
-		return errors.New("only one of --major, --minor, and --patch is allowed")
+	if (c.Major && (c.Minor || c.Patch || c.Graduate)) ||
+		(c.Minor && (c.Patch || c.Graduate)) ||
+		(c.Patch && c.Graduate) {
+		return errors.New("only one of --major, --minor, --patch, and --graduate is allowed")
+	}
+	for _, name := range c.Policies {
+		if _, err := c.resolvePolicy(name, nil); err != nil {
+			return err
+		}
+	}
+	for _, pol := range c.CustomPolicies {
+		if _, err := c.resolvePolicy(pol.Name, nil); err != nil {
+			return err
+		}
+	}
+	if err := validateVersionScheme(c.VersionScheme, c.CalverFormat); err != nil {
+		return err
+	}
+	for name, sc := range c.Scopes {
+		if name == "" {
+			return errors.New("scopes: scope name must not be empty")
+		}
+		if err := validateVersionScheme(sc.VersionScheme, sc.CalverFormat); err != nil {
+			return fmt.Errorf("scope %q: %w", name, err)
+		}
+		for _, pol := range sc.Policies {
+			if _, err := c.resolvePolicy(pol, nil); err != nil {
+				return fmt.Errorf("scope %q: %w", name, err)
+			}
+		}
+		if err := validateVersionFiles(sc.VersionFiles); err != nil {
+			return fmt.Errorf("scope %q: %w", name, err)
+		}
+		for _, dep := range sc.DependsOn {
+			if dep == "" {
+				return fmt.Errorf("scope %q: depends_on: scope name must not be empty", name)
+			}
+		}
+	}
+	names := make([]string, 0, len(c.Scopes))
+	for name := range c.Scopes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if _, err := c.ScopeOrder(names); err != nil {
+		return err
+	}
+	for i := range c.Ignore {
+		if err := c.Ignore[i].validate(); err != nil {
+			return fmt.Errorf("ignore #%d: %w", i+1, err)
+		}
+	}
+	for name, rule := range c.Lint {
+		if err := rule.validate(name); err != nil {
+			return fmt.Errorf("lint rule %q: %w", name, err)
+		}
+	}
+	if err := validatePrereleaseChannels(c.PrereleaseChannels); err != nil {
+		return err
+	}
+	for _, pattern := range c.MaintenanceBranches {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("maintenance_branches: invalid pattern %q: %w", pattern, err)
+		}
+	}
+	if err := validateVersionFiles(c.VersionFiles); err != nil {
+		return err
+	}
+	for _, alias := range c.AliasTags {
+		if alias != AliasMajor && alias != AliasMinor {
+			return fmt.Errorf("alias_tags: invalid alias %q, must be %s or %s", alias, AliasMajor, AliasMinor)
+		}
+	}
+	for i, rule := range c.Rules {
+		if err := rule.validate(); err != nil {
+			return fmt.Errorf("rule %s: %w", rule.Label(i), err)
+		}
+	}
+	if err := c.validateVersionGroups(); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (c Config) validateVersionGroups() error {
+	var names []string
+	for name := range c.VersionGroups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	groupOf := make(map[string]string)
+	for _, name := range names {
+		members := c.VersionGroups[name]
+		if name == "" {
+			return errors.New("version_groups: group name must not be empty")
+		}
+		if len(members) < 2 {
+			return fmt.Errorf("version_groups: group %q must have at least two scopes", name)
+		}
+		first := c.ForScope(members[0])
+		for _, scope := range members {
+			if other, ok := groupOf[scope]; ok {
+				return fmt.Errorf("version_groups: scope %q is in groups %q and %q", scope, other, name)
+			}
+			groupOf[scope] = name
+			if sc := c.ForScope(scope); sc.VersionScheme != first.VersionScheme || sc.CalverFormat != first.CalverFormat {
+				return fmt.Errorf("version_groups: scopes in group %q must have the same version scheme", name)
+			}
+		}
+	}
+	return nil
+}
+
+// VersionGroup returns the scopes of the version group that scope is in, or
+// nil if it isn't in one.
+func (c Config) VersionGroup(scope string) []string {
+	for _, members := range c.VersionGroups {
+		for _, member := range members {
+			if member == scope {
+				return members
+			}
+		}
 	}
 	return nil
 }
 
+// Alias tag kinds.
+const (
+	AliasMajor = "major"
+	AliasMinor = "minor"
+)
+
+var prereleaseNameRE = regexp.MustCompile(`^[A-Za-z\d]+$`)
+
+func validatePrereleaseChannels(channels []string) error {
+	seen := make(map[string]bool)
+	for _, name := range channels {
+		if !prereleaseNameRE.MatchString(name) {
+			return fmt.Errorf("prerelease_channels: invalid channel name %q", name)
+		}
+		if seen[name] {
+			return fmt.Errorf("prerelease_channels: duplicate channel %q", name)
+		}
+		seen[name] = true
+	}
+	return nil
+}
+
+// PrereleaseChannel returns the position of the named prerelease in
+// PrereleaseChannels, or -1 if it isn't one of them.
+func (c Config) PrereleaseChannel(name string) int {
+	for i, ch := range c.PrereleaseChannels {
+		if ch == name {
+			return i
+		}
+	}
+	return -1
+}
+
 func (c Config) Printf(msg string, args ...interface{}) {
 	if c.Quiet {
 		return
@@ -88,43 +284,92 @@ func (c Config) Warning(msg string, args ...interface{}) {
 }
 
 func (c Config) GetPolicies() []*Policy {
-	policies := make(map[string]*Policy)
-	for _, name := range c.Policies {
-		if customPol := c.getCustomPolicy(name); customPol != nil {
-			policies[name] = customPol
-		} else if builtinPol := getBuiltinPolicy(name); builtinPol != nil {
-			policies[name] = builtinPol
-		} else {
-			panic(fmt.Sprintf("policy %q was not found", name))
-		}
-	}
-
 	pols := make([]*Policy, len(c.Policies))
 	for i, name := range c.Policies {
-		pols[i] = policies[name]
+		pol, err := c.resolvePolicy(name, nil)
+		if err != nil {
+			panic(err.Error())
+		}
+		pols[i] = pol
 	}
 
 	return pols
 }
 
 func (c Config) GetPolicy(name string) *Policy {
-	for _, pol := range c.CustomPolicies {
-		if pol.Name == name {
-			return &pol
+	pol, err := c.resolvePolicy(name, nil)
+	if err != nil {
+		return nil
+	}
+	return pol
+}
+
+// resolvePolicy finds the named policy, applying any policies it extends. A
+// custom policy can extend the built-in policy of the same name.
+func (c Config) resolvePolicy(name string, seen []string) (*Policy, error) {
+	for _, prev := range seen {
+		if prev == name {
+			return nil, fmt.Errorf("policy %q extends itself: %s", name, strings.Join(append(seen, name), " -> "))
 		}
 	}
-	for _, pol := range builtinPolicies {
-		if pol.Name == name {
-			return &pol
+
+	customPol := c.getCustomPolicy(name)
+	if customPol == nil {
+		if builtinPol := getBuiltinPolicy(name); builtinPol != nil {
+			return builtinPol, nil
+		}
+		return nil, fmt.Errorf("policy %q was not found", name)
+	}
+	if customPol.Extends == "" {
+		return customPol, nil
+	}
+
+	var base *Policy
+	if customPol.Extends == name {
+		base = getBuiltinPolicy(name)
+		if base == nil {
+			return nil, fmt.Errorf("policy %q extends itself", name)
+		}
+	} else {
+		var err error
+		base, err = c.resolvePolicy(customPol.Extends, append(seen, name))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return customPol.extend(base), nil
+}
+
+// validateVersionScheme checks the scheme name. Calver formats are validated
+// when the scheme is created.
+func validateVersionScheme(scheme, calverFormat string) error {
+	switch scheme {
+	case "", "semver", "four_part":
+		if calverFormat != "" {
+			return errors.New("calver_format requires version_scheme: calver")
 		}
+	case "calver":
+	default:
+		return fmt.Errorf("unknown version scheme %q", scheme)
 	}
 	return nil
 }
 
 func (c Config) GetBranches() []string { return c.Branches }
 
+// IsMaintenanceBranch returns true if the branch matches one of
+// MaintenanceBranches.
+func (c Config) IsMaintenanceBranch(branch string) bool {
+	for _, pattern := range c.MaintenanceBranches {
+		if ok, _ := path.Match(pattern, branch); ok {
+			return true
+		}
+	}
+	return false
+}
+
 func (c Config) OverridesSet() bool {
-	return (c.Major || c.Minor || c.Patch)
+	return (c.Major || c.Minor || c.Patch || c.Graduate)
 }
 
 func (c Config) getCustomPolicy(name string) *Policy {
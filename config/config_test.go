@@ -1,6 +1,9 @@
 package config
 
-import "testing"
+import (
+	"strings"
+	"testing"
+)
 
 func TestConfig(t *testing.T) {
 	cfg := New(nil)
@@ -8,3 +11,444 @@ func TestConfig(t *testing.T) {
 		t.Fatalf("expected %d policies, got %d", 2, len(cfg.Policies))
 	}
 }
+
+func TestMatchPath(t *testing.T) {
+	tcs := []struct {
+		glob   string
+		path   string
+		expect bool
+	}{
+		{glob: "api/proto/**", path: "api/proto/v1/service.proto", expect: true},
+		{glob: "api/proto/**", path: "api/proto", expect: true},
+		{glob: "api/proto/**", path: "api/http/server.go", expect: false},
+		{glob: "**/*.proto", path: "api/proto/v1/service.proto", expect: true},
+		{glob: "**/*.proto", path: "service.proto", expect: true},
+		{glob: "*.go", path: "cmd/main.go", expect: false},
+		{glob: "cmd/*/main.go", path: "cmd/tunk/main.go", expect: true},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.glob+":"+tc.path, func(t *testing.T) {
+			if got := MatchPath(tc.glob, tc.path); got != tc.expect {
+				t.Errorf("expected %v, got %v", tc.expect, got)
+			}
+		})
+	}
+}
+
+func TestValidateRules(t *testing.T) {
+	tcs := []struct {
+		name       string
+		rule       Rule
+		shouldFail bool
+	}{
+		{
+			name: "type",
+			rule: Rule{Condition: Condition{Author: `dependabot\[bot\]`}, Type: "PATCH"},
+		},
+		{
+			name: "min-type",
+			rule: Rule{Condition: Condition{Paths: []string{"api/proto/**"}}, MinType: "MINOR"},
+		},
+		{
+			name:       "both-types",
+			rule:       Rule{Condition: Condition{Scope: "cool"}, Type: "PATCH", MinType: "MINOR"},
+			shouldFail: true,
+		},
+		{
+			name:       "no-type",
+			rule:       Rule{Condition: Condition{Scope: "cool"}},
+			shouldFail: true,
+		},
+		{
+			name:       "invalid-type",
+			rule:       Rule{Condition: Condition{Scope: "cool"}, Type: "HUGE"},
+			shouldFail: true,
+		},
+		{
+			name:       "empty-condition",
+			rule:       Rule{Type: "PATCH"},
+			shouldFail: true,
+		},
+		{
+			name:       "invalid-regex",
+			rule:       Rule{Condition: Condition{Body: "("}, Type: "PATCH"},
+			shouldFail: true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := New(&Config{Rules: []Rule{tc.rule}})
+			err := cfg.Validate()
+			if tc.shouldFail && err == nil {
+				t.Fatal("expected validation error")
+			} else if !tc.shouldFail && err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestPolicyExtends(t *testing.T) {
+	cfg := New(&Config{
+		Policies: []string{"ours"},
+		CustomPolicies: []Policy{
+			{
+				Name:                "ours",
+				Extends:             "conventional-lax",
+				BreakingChangeTypes: []string{"BREAKING"},
+				CommitTypes: map[string]string{
+					"chore": "PATCH",
+					"build": "PATCH",
+				},
+			},
+		},
+	})
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	pols := cfg.GetPolicies()
+	if len(pols) != 1 {
+		t.Fatalf("expected 1 policy, got %d", len(pols))
+	}
+	pol := pols[0]
+	base := getBuiltinPolicy("conventional-lax")
+	if pol.Name != "ours" {
+		t.Errorf("expected name %q, got %q", "ours", pol.Name)
+	}
+	if pol.SubjectRE != base.SubjectRE {
+		t.Errorf("expected subject regex %q, got %q", base.SubjectRE, pol.SubjectRE)
+	}
+	if len(pol.BreakingChangeTypes) != 1 || pol.BreakingChangeTypes[0] != "BREAKING" {
+		t.Errorf("expected breaking change annotations to be overridden, got %q", pol.BreakingChangeTypes)
+	}
+	expectTypes := map[string]string{"feat": "MINOR", "chore": "PATCH", "build": "PATCH", "docs": "SKIP"}
+	for k, v := range expectTypes {
+		if pol.CommitTypes[k] != v {
+			t.Errorf("expected commit type %q to be %q, got %q", k, v, pol.CommitTypes[k])
+		}
+	}
+	if base.CommitTypes["chore"] != "SKIP" {
+		t.Error("expected built-in policy to be unchanged")
+	}
+}
+
+func TestPolicyExtendsInvalid(t *testing.T) {
+	tcs := []struct {
+		name     string
+		policies []Policy
+	}{
+		{
+			name:     "missing",
+			policies: []Policy{{Name: "ours", Extends: "nope"}},
+		},
+		{
+			name:     "self",
+			policies: []Policy{{Name: "ours", Extends: "ours"}},
+		},
+		{
+			name: "cycle",
+			policies: []Policy{
+				{Name: "ours", Extends: "theirs"},
+				{Name: "theirs", Extends: "ours"},
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := New(&Config{Policies: []string{"ours"}, CustomPolicies: tc.policies})
+			if err := cfg.Validate(); err == nil {
+				t.Fatal("expected validation error")
+			} else {
+			}
+		})
+	}
+}
+
+func TestPolicyExtendsBuiltinName(t *testing.T) {
+	cfg := New(&Config{
+		Policies: []string{"lax"},
+		CustomPolicies: []Policy{
+			{Name: "lax", Extends: "lax", FallbackReleaseType: "MINOR"},
+		},
+	})
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	pol := cfg.GetPolicy("lax")
+	if pol.FallbackReleaseType != "MINOR" {
+		t.Errorf("expected fallback type %q, got %q", "MINOR", pol.FallbackReleaseType)
+	}
+	if pol.SubjectRE == "" {
+		t.Error("expected subject regex to be inherited")
+	}
+}
+
+func TestForScope(t *testing.T) {
+	cfg := New(&Config{
+		TagTemplate:  "v{{ semver .Version }}",
+		AllowedTypes: []string{"feat", "fix"},
+		Scopes: map[string]ScopeConfig{
+			"sdk": {
+				Policies:    []string{"conventional"},
+				TagTemplate: "sdk-v{{ semver .Version }}",
+			},
+		},
+	})
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	sdk := cfg.ForScope("sdk")
+	if len(sdk.Policies) != 1 || sdk.Policies[0] != "conventional" {
+		t.Errorf("expected sdk policies [conventional], got %q", sdk.Policies)
+	}
+	if sdk.TagTemplate != "sdk-v{{ semver .Version }}" {
+		t.Errorf("expected sdk tag template to be overridden, got %q", sdk.TagTemplate)
+	}
+	if len(sdk.AllowedTypes) != 2 {
+		t.Errorf("expected sdk to inherit allowed types, got %q", sdk.AllowedTypes)
+	}
+
+	other := cfg.ForScope("other")
+	if len(other.Policies) != 2 || other.TagTemplate != cfg.TagTemplate {
+		t.Errorf("expected unconfigured scope to use the top-level config, got %q, %q", other.Policies, other.TagTemplate)
+	}
+
+	cfg.Scopes["sdk"] = ScopeConfig{Policies: []string{"nope"}}
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected invalid scope policy to fail validation")
+	}
+}
+
+func TestValidateVersionScheme(t *testing.T) {
+	tcs := []struct {
+		name       string
+		cfg        *Config
+		shouldFail bool
+	}{
+		{name: "default", cfg: &Config{}},
+		{name: "calver", cfg: &Config{VersionScheme: "calver", CalverFormat: "YY.0M.MICRO"}},
+		{name: "four-part", cfg: &Config{VersionScheme: "four_part"}},
+		{name: "unknown", cfg: &Config{VersionScheme: "romver"}, shouldFail: true},
+		{name: "format-without-calver", cfg: &Config{CalverFormat: "YYYY.MM.MICRO"}, shouldFail: true},
+		{name: "scope", cfg: &Config{Scopes: map[string]ScopeConfig{"cool": {VersionScheme: "nope"}}}, shouldFail: true},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			err := New(tc.cfg).Validate()
+			if tc.shouldFail && err == nil {
+				t.Fatal("expected validation to fail")
+			} else if !tc.shouldFail && err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestValidatePrereleaseChannels(t *testing.T) {
+	tcs := []struct {
+		name       string
+		channels   []string
+		shouldFail bool
+	}{
+		{name: "basic", channels: []string{"alpha", "beta", "rc"}},
+		{name: "invalid", channels: []string{"alpha", "release-candidate"}, shouldFail: true},
+		{name: "empty", channels: []string{""}, shouldFail: true},
+		{name: "duplicate", channels: []string{"beta", "rc", "beta"}, shouldFail: true},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			err := New(&Config{PrereleaseChannels: tc.channels}).Validate()
+			if tc.shouldFail && err == nil {
+				t.Fatal("expected validation to fail")
+			} else if !tc.shouldFail && err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestValidateVersionFiles(t *testing.T) {
+	tcs := []struct {
+		name       string
+		file       VersionFile
+		shouldFail bool
+	}{
+		{name: "json", file: VersionFile{Path: "package.json", Format: "json", Key: "version"}},
+		{name: "toml", file: VersionFile{Path: "Cargo.toml", Format: "toml", Key: "package.version"}},
+		{name: "regex", file: VersionFile{Path: "VERSION", Format: "regex", Pattern: `\A(\S+)`}},
+		{name: "no-path", file: VersionFile{Format: "json", Key: "version"}, shouldFail: true},
+		{name: "no-key", file: VersionFile{Path: "package.json", Format: "json"}, shouldFail: true},
+		{name: "no-group", file: VersionFile{Path: "VERSION", Format: "regex", Pattern: `\S+`}, shouldFail: true},
+		{name: "bad-pattern", file: VersionFile{Path: "VERSION", Format: "regex", Pattern: `(`}, shouldFail: true},
+		{name: "unknown-format", file: VersionFile{Path: "setup.py", Format: "python"}, shouldFail: true},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			err := New(&Config{VersionFiles: []VersionFile{tc.file}}).Validate()
+			if tc.shouldFail && err == nil {
+				t.Fatal("expected validation to fail")
+			} else if !tc.shouldFail && err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestValidateAliasTags(t *testing.T) {
+	tcs := []struct {
+		name       string
+		aliases    []string
+		shouldFail bool
+	}{
+		{name: "major-minor", aliases: []string{"major", "minor"}},
+		{name: "minor", aliases: []string{"minor"}},
+		{name: "patch", aliases: []string{"patch"}, shouldFail: true},
+		{name: "template", aliases: []string{"v{{ .Version.Major }}"}, shouldFail: true},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			err := New(&Config{AliasTags: tc.aliases}).Validate()
+			if tc.shouldFail && err == nil {
+				t.Fatal("expected validation to fail")
+			} else if !tc.shouldFail && err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestScopeOrder(t *testing.T) {
+	tcs := []struct {
+		name       string
+		deps       map[string][]string
+		groups     map[string][]string
+		scopes     []string
+		expect     []string
+		shouldFail bool
+	}{
+		{
+			name:   "none",
+			scopes: []string{"", "cli", "sdk"},
+			expect: []string{"", "cli", "sdk"},
+		},
+		{
+			name:   "dependency",
+			deps:   map[string][]string{"cli": {"sdk"}},
+			scopes: []string{"", "cli", "sdk"},
+			expect: []string{"", "sdk", "cli"},
+		},
+		{
+			name:   "chain",
+			deps:   map[string][]string{"app": {"cli"}, "cli": {"sdk", "util"}, "sdk": {"util"}},
+			scopes: []string{"app", "cli", "sdk", "util"},
+			expect: []string{"util", "sdk", "cli", "app"},
+		},
+		{
+			name:   "unreleased-dependency",
+			deps:   map[string][]string{"cli": {"sdk"}},
+			scopes: []string{"cli"},
+			expect: []string{"cli"},
+		},
+		{
+			name:   "group",
+			deps:   map[string][]string{"server": {"sdk"}, "cli": {"client"}},
+			groups: map[string][]string{"platform": {"client", "server"}},
+			scopes: []string{"", "cli", "client", "sdk", "server"},
+			expect: []string{"", "sdk", "client", "server", "cli"},
+		},
+		{
+			name:   "group-member-dependency",
+			deps:   map[string][]string{"client": {"server"}},
+			groups: map[string][]string{"platform": {"client", "server"}},
+			scopes: []string{"server", "client"},
+			expect: []string{"client", "server"},
+		},
+		{
+			name:       "cycle",
+			deps:       map[string][]string{"cli": {"sdk"}, "sdk": {"util"}, "util": {"cli"}},
+			scopes:     []string{"cli"},
+			shouldFail: true,
+		},
+		{
+			name:       "self",
+			deps:       map[string][]string{"cli": {"cli"}},
+			scopes:     []string{"cli"},
+			shouldFail: true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			scopes := make(map[string]ScopeConfig)
+			for name, deps := range tc.deps {
+				scopes[name] = ScopeConfig{DependsOn: deps}
+			}
+			cfg := New(&Config{Scopes: scopes, VersionGroups: tc.groups})
+			if err := cfg.Validate(); tc.shouldFail && err == nil {
+				t.Fatal("expected validation to fail")
+			} else if !tc.shouldFail && err != nil {
+				t.Fatal(err)
+			}
+
+			order, err := cfg.ScopeOrder(tc.scopes)
+			if tc.shouldFail {
+				if err == nil {
+					t.Fatal("expected cycle error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Join(order, ",") != strings.Join(tc.expect, ",") {
+				t.Errorf("expected order %q, got %q", tc.expect, order)
+			}
+		})
+	}
+}
+
+func TestValidateVersionGroups(t *testing.T) {
+	tcs := []struct {
+		name       string
+		cfg        *Config
+		shouldFail bool
+	}{
+		{name: "group", cfg: &Config{VersionGroups: map[string][]string{"platform": {"client", "server"}}}},
+		{name: "main-scope", cfg: &Config{VersionGroups: map[string][]string{"platform": {"", "server"}}}},
+		{name: "one-scope", cfg: &Config{VersionGroups: map[string][]string{"platform": {"client"}}}, shouldFail: true},
+		{name: "no-name", cfg: &Config{VersionGroups: map[string][]string{"": {"client", "server"}}}, shouldFail: true},
+		{
+			name:       "two-groups",
+			cfg:        &Config{VersionGroups: map[string][]string{"platform": {"client", "server"}, "tools": {"cli", "server"}}},
+			shouldFail: true,
+		},
+		{
+			name: "schemes",
+			cfg: &Config{
+				VersionGroups: map[string][]string{"platform": {"client", "server"}},
+				Scopes:        map[string]ScopeConfig{"server": {VersionScheme: "calver"}},
+			},
+			shouldFail: true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			err := New(tc.cfg).Validate()
+			if tc.shouldFail && err == nil {
+				t.Fatal("expected validation to fail")
+			} else if !tc.shouldFail && err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
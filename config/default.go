@@ -4,5 +4,7 @@ func GetDefault() Config {
 	return Config{
 		Policies: []string{"conventional-lax", "lax"},
 		Branches: []string{"main", "master"},
+
+		ScopeSeparator: ",",
 	}
 }
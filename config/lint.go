@@ -0,0 +1,67 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Lint rule names.
+const (
+	LintSubjectMaxLength        = "subject_max_length"
+	LintSubjectNoTrailingPeriod = "subject_no_trailing_period"
+	LintTypeLowerCase           = "type_lower_case"
+	LintBodyLeadingBlank        = "body_leading_blank"
+	LintBodyMaxLineLength       = "body_max_line_length"
+	LintRequiredTrailers        = "required_trailers"
+	LintForbiddenWords          = "forbidden_words"
+)
+
+// LintRuleNames are the available lint rules, in the order they're checked.
+var LintRuleNames = []string{
+	LintSubjectMaxLength,
+	LintSubjectNoTrailingPeriod,
+	LintTypeLowerCase,
+	LintBodyLeadingBlank,
+	LintBodyMaxLineLength,
+	LintRequiredTrailers,
+	LintForbiddenWords,
+}
+
+// Lint rule severities.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// LintRule configures a commit message lint rule used by tunk --check. Max is
+// used by the length rules, and Values by required_trailers and
+// forbidden_words.
+type LintRule struct {
+	Severity string   `json:"severity,omitempty"`
+	Max      int      `json:"max,omitempty"`
+	Values   []string `json:"values,omitempty"`
+}
+
+// IsWarning returns true if violations of the rule shouldn't fail checks.
+func (r LintRule) IsWarning() bool { return r.Severity == SeverityWarning }
+
+func (r LintRule) validate(name string) error {
+	switch r.Severity {
+	case "", SeverityError, SeverityWarning:
+	default:
+		return fmt.Errorf("invalid severity %q", r.Severity)
+	}
+	if r.Max < 0 {
+		return errors.New("max must not be negative")
+	}
+	switch name {
+	case LintRequiredTrailers, LintForbiddenWords:
+		if len(r.Values) == 0 {
+			return errors.New("values are required")
+		}
+	case LintSubjectMaxLength, LintSubjectNoTrailingPeriod, LintTypeLowerCase, LintBodyLeadingBlank, LintBodyMaxLineLength:
+	default:
+		return errors.New("unknown lint rule")
+	}
+	return nil
+}
@@ -5,15 +5,18 @@ import (
 	"fmt"
 	"io"
 	"regexp"
+	"sort"
 	"strings"
 )
 
 type Policy struct {
 	Name                  string            `json:"name"`
+	Extends               string            `json:"extends,omitempty"`
 	SubjectRE             string            `json:"subject_regex"`
 	BodyAnnotationStartRE string            `json:"body_annotation_start_regex"`
 	BreakingChangeTypes   []string          `json:"breaking_change_annotations"`
 	CommitTypes           map[string]string `json:"commit_types"`
+	DefaultReleaseType    string            `json:"default_type,omitempty"`
 	FallbackReleaseType   string            `json:"fallback_type,omitempty"`
 	subjectRE             *regexp.Regexp
 	bodyRE                *regexp.Regexp
@@ -43,6 +46,9 @@ func (p *Policy) TextSummary(w io.Writer) error {
 	bw := bufio.NewWriter(w)
 
 	bw.WriteString(fmt.Sprintf("Name: %s\n", p.Name))
+	if p.Extends != "" {
+		bw.WriteString(fmt.Sprintf("Extends: %s\n", p.Extends))
+	}
 
 	if p.SubjectRE != "" {
 		bw.WriteString(fmt.Sprintf("Subject regexp: %s\n", p.SubjectRE))
@@ -57,11 +63,19 @@ func (p *Policy) TextSummary(w io.Writer) error {
 
 	if len(p.CommitTypes) > 0 {
 		bw.WriteString("Commit types:\n")
-		for k, v := range p.CommitTypes {
-			bw.WriteString(fmt.Sprintf("  %16s: %16s\n", k, v))
+		keys := make([]string, 0, len(p.CommitTypes))
+		for k := range p.CommitTypes {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			bw.WriteString(fmt.Sprintf("  %16s: %16s\n", k, p.CommitTypes[k]))
 		}
 	}
 
+	if p.DefaultReleaseType != "" {
+		bw.WriteString(fmt.Sprintf("Default release type: %s\n", p.DefaultReleaseType))
+	}
 	if p.FallbackReleaseType != "" {
 		bw.WriteString(fmt.Sprintf("Fallback release type: %s\n", p.FallbackReleaseType))
 	}
@@ -94,6 +108,109 @@ var builtinPolicies = []Policy{
 		SubjectRE:           `^(?P<scope>[A-Za-z0-9_-]+): `,
 		FallbackReleaseType: "PATCH",
 	},
+	{
+		Name:                  "conventional",
+		SubjectRE:             `^(?P<type>[a-z]+)(?:\((?P<scope>[a-z0-9][a-z0-9_/.,-]*)\))?(?P<breaking>!)?: (?P<body>\S.*)$`,
+		BodyAnnotationStartRE: `^(?P<name>BREAKING[ -]CHANGE|[A-Za-z][A-Za-z-]*): `,
+		BreakingChangeTypes:   []string{"BREAKING CHANGE", "BREAKING-CHANGE"},
+		CommitTypes: map[string]string{
+			"feat":     "MINOR",
+			"fix":      "PATCH",
+			"perf":     "PATCH",
+			"revert":   "PATCH",
+			"build":    "SKIP",
+			"chore":    "SKIP",
+			"ci":       "SKIP",
+			"docs":     "SKIP",
+			"refactor": "SKIP",
+			"style":    "SKIP",
+			"test":     "SKIP",
+		},
+	},
+	{
+		Name:                  "angular",
+		SubjectRE:             `^(?P<type>[a-z]+)(?:\((?P<scope>[a-z0-9][a-z0-9_/.,-]*)\))?: (?P<body>\S.*)$`,
+		BodyAnnotationStartRE: `^(?P<name>BREAKING CHANGE|DEPRECATED): `,
+		BreakingChangeTypes:   []string{"BREAKING CHANGE"},
+		CommitTypes: map[string]string{
+			"feat":     "MINOR",
+			"fix":      "PATCH",
+			"perf":     "PATCH",
+			"revert":   "PATCH",
+			"build":    "SKIP",
+			"ci":       "SKIP",
+			"docs":     "SKIP",
+			"refactor": "SKIP",
+			"test":     "SKIP",
+		},
+	},
+	{
+		Name:                  "gitmoji",
+		SubjectRE:             `^(?P<type>:[a-z0-9_+-]+:|\p{So})\x{FE0F}?\s*(?:\((?P<scope>[^\)\s]+)\):?\s*)?(?P<body>\S.*)$`,
+		BodyAnnotationStartRE: `^(?P<name>[A-Z ]+): `,
+		BreakingChangeTypes:   []string{"BREAKING CHANGE"},
+		CommitTypes:           gitmojiCommitTypes(),
+	},
+	{
+		Name:                  "jira",
+		SubjectRE:             `^\[?(?P<ticket>[A-Z][A-Z0-9]+-[1-9][0-9]*)\]?:?\s+(?P<body>\S.*)$`,
+		BodyAnnotationStartRE: `^(?P<name>[A-Z ]+): `,
+		BreakingChangeTypes:   []string{"BREAKING CHANGE"},
+		DefaultReleaseType:    "PATCH",
+	},
+	{
+		Name:               "deps",
+		SubjectRE:          `^(?:(?:build|chore|fix)\(deps(?:-dev)?\):\s+)?(?:[Bb]ump|[Uu]pdate)\s+(?P<body>.+\s(?:from|to)\s.+|the\s.+\sgroup.*)$`,
+		DefaultReleaseType: "PATCH",
+	},
+}
+
+// gitmojiCommitTypes maps gitmoji (https://gitmoji.dev), as both codes and
+// characters, to release types.
+func gitmojiCommitTypes() map[string]string {
+	emojis := []struct {
+		code, char, releaseType string
+	}{
+		{":boom:", "💥", "MAJOR"},
+		{":sparkles:", "✨", "MINOR"},
+		{":bug:", "🐛", "PATCH"},
+		{":ambulance:", "🚑", "PATCH"},
+		{":lock:", "🔒", "PATCH"},
+		{":zap:", "⚡", "PATCH"},
+		{":lipstick:", "💄", "PATCH"},
+		{":arrow_up:", "⬆", "PATCH"},
+		{":arrow_down:", "⬇", "PATCH"},
+		{":pushpin:", "📌", "PATCH"},
+		{":alien:", "👽", "PATCH"},
+		{":bento:", "🍱", "PATCH"},
+		{":wheelchair:", "♿", "PATCH"},
+		{":speech_balloon:", "💬", "PATCH"},
+		{":children_crossing:", "🚸", "PATCH"},
+		{":globe_with_meridians:", "🌐", "PATCH"},
+		{":pencil2:", "✏", "PATCH"},
+		{":goal_net:", "🥅", "PATCH"},
+		{":adhesive_bandage:", "🩹", "PATCH"},
+		{":memo:", "📝", "SKIP"},
+		{":white_check_mark:", "✅", "SKIP"},
+		{":wrench:", "🔧", "SKIP"},
+		{":recycle:", "♻", "SKIP"},
+		{":art:", "🎨", "SKIP"},
+		{":fire:", "🔥", "SKIP"},
+		{":rocket:", "🚀", "SKIP"},
+		{":green_heart:", "💚", "SKIP"},
+		{":construction_worker:", "👷", "SKIP"},
+		{":tada:", "🎉", "SKIP"},
+		{":bookmark:", "🔖", "SKIP"},
+		{":rotating_light:", "🚨", "SKIP"},
+		{":see_no_evil:", "🙈", "SKIP"},
+	}
+
+	types := make(map[string]string)
+	for _, e := range emojis {
+		types[e.code] = e.releaseType
+		types[e.char] = e.releaseType
+	}
+	return types
 }
 
 func getBuiltinPolicy(name string) *Policy {
@@ -104,3 +221,43 @@ func getBuiltinPolicy(name string) *Policy {
 	}
 	return nil
 }
+
+// extend returns a copy of base with the policy's attributes applied on top of
+// it. Commit types are merged, and all other attributes are replaced if set.
+func (p Policy) extend(base *Policy) *Policy {
+	res := &Policy{
+		Name:                  p.Name,
+		Extends:               p.Extends,
+		SubjectRE:             base.SubjectRE,
+		BodyAnnotationStartRE: base.BodyAnnotationStartRE,
+		BreakingChangeTypes:   base.BreakingChangeTypes,
+		DefaultReleaseType:    base.DefaultReleaseType,
+		FallbackReleaseType:   base.FallbackReleaseType,
+	}
+	if p.SubjectRE != "" {
+		res.SubjectRE = p.SubjectRE
+	}
+	if p.BodyAnnotationStartRE != "" {
+		res.BodyAnnotationStartRE = p.BodyAnnotationStartRE
+	}
+	if p.BreakingChangeTypes != nil {
+		res.BreakingChangeTypes = p.BreakingChangeTypes
+	}
+	if p.DefaultReleaseType != "" {
+		res.DefaultReleaseType = p.DefaultReleaseType
+	}
+	if p.FallbackReleaseType != "" {
+		res.FallbackReleaseType = p.FallbackReleaseType
+	}
+
+	if len(base.CommitTypes) > 0 || len(p.CommitTypes) > 0 {
+		res.CommitTypes = make(map[string]string)
+		for k, v := range base.CommitTypes {
+			res.CommitTypes[k] = v
+		}
+		for k, v := range p.CommitTypes {
+			res.CommitTypes[k] = v
+		}
+	}
+	return res
+}
@@ -0,0 +1,209 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/jeffrom/tunk/model"
+)
+
+// Condition matches commits by their attributes. Every non-empty field must
+// match for the condition to match. Author, committer, subject, body, and
+// trailer values are regular expressions. Paths are globs that may contain
+// "**" to match any number of directories.
+type Condition struct {
+	Subject   string            `json:"subject,omitempty"`
+	Author    string            `json:"author,omitempty"`
+	Committer string            `json:"committer,omitempty"`
+	Body      string            `json:"body,omitempty"`
+	Trailers  map[string]string `json:"trailers,omitempty"`
+	Paths     []string          `json:"paths,omitempty"`
+	Scope     string            `json:"scope,omitempty"`
+	res       map[string]*regexp.Regexp
+}
+
+// Rule assigns a release type to commits matching its condition. Type sets
+// the release type outright, while MinType only raises it.
+type Rule struct {
+	Name string `json:"name,omitempty"`
+	Condition
+	Type    string `json:"type,omitempty"`
+	MinType string `json:"min_type,omitempty"`
+}
+
+// Label returns the rule's name, or its position if it has none.
+func (r Rule) Label(i int) string {
+	if r.Name != "" {
+		return r.Name
+	}
+	return fmt.Sprintf("#%d", i+1)
+}
+
+func (r Rule) validate() error {
+	if (r.Type == "") == (r.MinType == "") {
+		return errors.New("exactly one of type and min_type is required")
+	}
+	if r.Type != "" && !validReleaseType(r.Type) {
+		return fmt.Errorf("invalid type %q", r.Type)
+	}
+	if r.MinType != "" && !validReleaseType(r.MinType) {
+		return fmt.Errorf("invalid min_type %q", r.MinType)
+	}
+	return r.Condition.validate()
+}
+
+func (c *Condition) validate() error {
+	if c.empty() {
+		return errors.New("condition is empty")
+	}
+	for name, expr := range c.exprs() {
+		if _, err := regexp.Compile(expr); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	for _, p := range c.Paths {
+		if _, err := path.Match(strings.ReplaceAll(p, "**", "*"), ""); err != nil {
+			return fmt.Errorf("paths: %q: %w", p, err)
+		}
+	}
+	return nil
+}
+
+func (c *Condition) empty() bool {
+	return c.Subject == "" && c.Author == "" && c.Committer == "" &&
+		c.Body == "" && len(c.Trailers) == 0 && len(c.Paths) == 0 && c.Scope == ""
+}
+
+func (c *Condition) exprs() map[string]string {
+	exprs := make(map[string]string)
+	if c.Subject != "" {
+		exprs["subject"] = c.Subject
+	}
+	if c.Author != "" {
+		exprs["author"] = c.Author
+	}
+	if c.Committer != "" {
+		exprs["committer"] = c.Committer
+	}
+	if c.Body != "" {
+		exprs["body"] = c.Body
+	}
+	for k, v := range c.Trailers {
+		exprs["trailer "+k] = v
+	}
+	return exprs
+}
+
+func (c *Condition) getRE(name string) *regexp.Regexp {
+	if c.res == nil {
+		c.res = make(map[string]*regexp.Regexp)
+		for name, expr := range c.exprs() {
+			c.res[name] = regexp.MustCompile(expr)
+		}
+	}
+	return c.res[name]
+}
+
+// Match reports whether the commit, with the scopes read by its policy,
+// matches the condition. When it doesn't, the name of the first attribute
+// that failed to match is also returned.
+func (c *Condition) Match(commit *model.Commit, scopes ...string) (bool, string) {
+	if c.Scope != "" && !inStrs(c.Scope, scopes) {
+		return false, "scope"
+	}
+	if c.Subject != "" && !c.getRE("subject").MatchString(commit.Subject) {
+		return false, "subject"
+	}
+	if c.Author != "" && !c.getRE("author").MatchString(fmt.Sprintf("%s <%s>", commit.Author, commit.AuthorEmail)) {
+		return false, "author"
+	}
+	if c.Committer != "" && !c.getRE("committer").MatchString(fmt.Sprintf("%s <%s>", commit.Committer, commit.CommitterEmail)) {
+		return false, "committer"
+	}
+	if c.Body != "" && !c.getRE("body").MatchString(commit.Body) {
+		return false, "body"
+	}
+	for k := range c.Trailers {
+		re := c.getRE("trailer " + k)
+		found := false
+		for _, val := range commit.TrailerValues(k) {
+			if re.MatchString(val) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false, "trailer " + k
+		}
+	}
+	if len(c.Paths) > 0 && !matchAnyPath(c.Paths, commit.Files) {
+		return false, "paths"
+	}
+	return true, ""
+}
+
+// ReadsPaths reports whether any rule or ignore condition matches commits by
+// the paths they change, so the changed files of each commit must be read.
+func (c Config) ReadsPaths() bool {
+	for _, rule := range c.Rules {
+		if len(rule.Paths) > 0 {
+			return true
+		}
+	}
+	for _, cond := range c.Ignore {
+		if len(cond.Paths) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func matchAnyPath(globs, files []string) bool {
+	for _, f := range files {
+		for _, g := range globs {
+			if MatchPath(g, f) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// MatchPath reports whether the slash-separated path p matches glob. In
+// addition to path.Match syntax, a "**" element matches zero or more path
+// elements.
+func MatchPath(glob, p string) bool {
+	return matchPathParts(strings.Split(glob, "/"), strings.Split(p, "/"))
+}
+
+func matchPathParts(glob, parts []string) bool {
+	for len(glob) > 0 {
+		if glob[0] == "**" {
+			for i := 0; i <= len(parts); i++ {
+				if matchPathParts(glob[1:], parts[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(parts) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(glob[0], parts[0]); !ok {
+			return false
+		}
+		glob, parts = glob[1:], parts[1:]
+	}
+	return len(parts) == 0
+}
+
+func validReleaseType(s string) bool {
+	switch s {
+	case "SKIP", "PATCH", "MINOR", "MAJOR":
+		return true
+	}
+	return false
+}
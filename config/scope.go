@@ -0,0 +1,144 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ScopeConfig overrides the top-level configuration for a single scope. Unset
+// fields inherit the top-level value.
+type ScopeConfig struct {
+	Policies     []string `json:"policies,omitempty"`
+	Branches     []string `json:"branches,omitempty"`
+	AllowedTypes []string `json:"allowed_types,omitempty"`
+	TagTemplate  string   `json:"tag_template,omitempty"`
+	LogTemplate  string   `json:"log_template,omitempty"`
+
+	LegacyTagTemplates []string `json:"legacy_tag_templates,omitempty"`
+
+	VersionScheme string `json:"version_scheme,omitempty"`
+	CalverFormat  string `json:"calver_format,omitempty"`
+
+	RequireMajorApproval *bool `json:"require_major_approval,omitempty"`
+
+	// VersionFiles are the scope's version files. Unlike other settings, they
+	// aren't inherited from the top level.
+	VersionFiles []VersionFile `json:"version_files,omitempty"`
+
+	// DependsOn are the scopes the scope depends on. When they're released
+	// along with the scope, the scope is released too.
+	DependsOn []string `json:"depends_on,omitempty"`
+}
+
+// ForScope returns the effective configuration for scope: the top-level
+// configuration with the scope's settings applied.
+func (c Config) ForScope(scope string) Config {
+	sc, ok := c.Scopes[scope]
+	if scope == "" || !ok {
+		return c
+	}
+	if sc.Policies != nil {
+		c.Policies = sc.Policies
+	}
+	if sc.Branches != nil {
+		c.Branches = sc.Branches
+		c.BranchesSet = true
+	}
+	if sc.AllowedTypes != nil {
+		c.AllowedTypes = sc.AllowedTypes
+	}
+	if sc.TagTemplate != "" {
+		c.TagTemplate = sc.TagTemplate
+	}
+	if sc.LogTemplate != "" {
+		c.LogTemplate = sc.LogTemplate
+	}
+	if sc.LegacyTagTemplates != nil {
+		c.LegacyTagTemplates = sc.LegacyTagTemplates
+	}
+	if sc.VersionScheme != "" {
+		c.VersionScheme = sc.VersionScheme
+		c.CalverFormat = sc.CalverFormat
+	}
+	if sc.RequireMajorApproval != nil {
+		c.RequireMajorApproval = *sc.RequireMajorApproval
+	}
+	return c
+}
+
+// HasScopeConfig returns true if the scope has its own configuration.
+func (c Config) HasScopeConfig(scope string) bool {
+	_, ok := c.Scopes[scope]
+	return scope != "" && ok
+}
+
+// ScopeOrder sorts scopes so each scope follows the scopes it depends on, and
+// scopes in a version group are next to each other, following the scopes any
+// of them depend on. Otherwise, scopes keep their order. It returns an error if
+// the dependencies form a cycle.
+func (c Config) ScopeOrder(scopes []string) ([]string, error) {
+	want := make(map[string]bool)
+	for _, scope := range scopes {
+		want[scope] = true
+	}
+	const (
+		visiting = iota + 1
+		visited
+	)
+	state := make(map[string]int)
+	var order []string
+	var visit func(scope string, path []string) error
+	visit = func(scope string, path []string) error {
+		switch state[scope] {
+		case visiting:
+			for i, s := range path {
+				if s == scope {
+					path = path[i:]
+					break
+				}
+			}
+			return fmt.Errorf("scopes: dependency cycle: %s", strings.Join(append(path, scope), " -> "))
+		case visited:
+			return nil
+		}
+		members := c.VersionGroup(scope)
+		if members == nil {
+			members = []string{scope}
+		}
+		for _, member := range members {
+			state[member] = visiting
+		}
+		for _, member := range members {
+			for _, dep := range c.Scopes[member].DependsOn {
+				if inStrs(dep, members) && dep != member {
+					continue
+				}
+				if err := visit(dep, append(path, member)); err != nil {
+					return err
+				}
+			}
+		}
+		for _, member := range members {
+			state[member] = visited
+			if want[member] {
+				order = append(order, member)
+			}
+		}
+		return nil
+	}
+	for _, scope := range scopes {
+		if err := visit(scope, nil); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+func inStrs(s string, cands []string) bool {
+	for _, cand := range cands {
+		if s == cand {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,69 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// Version file formats.
+const (
+	VersionFileJSON  = "json"
+	VersionFileTOML  = "toml"
+	VersionFileRegex = "regex"
+)
+
+// VersionFile is a file that tunk writes the released version to before
+// tagging. Key is the dot-separated path of the version for json and toml
+// files, such as "version" or "tool.poetry.version". Pattern is a regular
+// expression whose first capture group is replaced by the version.
+type VersionFile struct {
+	Path    string `json:"path"`
+	Format  string `json:"format"`
+	Key     string `json:"key,omitempty"`
+	Pattern string `json:"pattern,omitempty"`
+}
+
+func (f VersionFile) validate() error {
+	if f.Path == "" {
+		return errors.New("path is required")
+	}
+	switch f.Format {
+	case VersionFileJSON, VersionFileTOML:
+		if f.Key == "" {
+			return fmt.Errorf("key is required for format %q", f.Format)
+		}
+	case VersionFileRegex:
+		if f.Pattern == "" {
+			return errors.New("pattern is required for format \"regex\"")
+		}
+		re, err := regexp.Compile(f.Pattern)
+		if err != nil {
+			return err
+		}
+		if re.NumSubexp() < 1 {
+			return errors.New("pattern must have a capture group")
+		}
+	default:
+		return fmt.Errorf("invalid format %q, must be one of json, toml, regex", f.Format)
+	}
+	return nil
+}
+
+func validateVersionFiles(files []VersionFile) error {
+	for i, f := range files {
+		if err := f.validate(); err != nil {
+			return fmt.Errorf("version_files #%d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// VersionFilesFor returns the version files of the scope's releases. The
+// top-level version files are only used for the main scope.
+func (c Config) VersionFilesFor(scope string) []VersionFile {
+	if scope == "" {
+		return c.VersionFiles
+	}
+	return c.Scopes[scope].VersionFiles
+}
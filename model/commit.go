@@ -1,6 +1,10 @@
 package model
 
-import "time"
+import (
+	"regexp"
+	"strings"
+	"time"
+)
 
 type Commit struct {
 	ID             string `json:"commit"`
@@ -13,6 +17,10 @@ type Commit struct {
 	Subject        string
 	Body           string
 	Ref            string
+	// Message is the raw commit message, if it was read.
+	Message string `json:"-"`
+	// Files are the paths changed by the commit, if they were read.
+	Files []string `json:"files,omitempty"`
 	// Branch string `json:"branch,omitempty"`
 }
 
@@ -22,3 +30,48 @@ func (c *Commit) ShortID() string {
 	}
 	return c.ID[:8]
 }
+
+// Trailer is a git trailer, such as "Signed-off-by: Jeff <jeff@example.com>".
+type Trailer struct {
+	Key   string
+	Value string
+}
+
+var trailerRE = regexp.MustCompile(`^(?P<key>[A-Za-z0-9][A-Za-z0-9-]*):\s+(?P<value>.*)$`)
+
+// Trailers returns the trailers found in the last paragraph of the commit
+// body, in order.
+func (c *Commit) Trailers() []Trailer {
+	body := strings.TrimRight(c.Body, "\n ")
+	if body == "" {
+		return nil
+	}
+	paragraphs := strings.Split(body, "\n\n")
+	last := paragraphs[len(paragraphs)-1]
+
+	var trailers []Trailer
+	for _, line := range strings.Split(last, "\n") {
+		m := trailerRE.FindStringSubmatch(line)
+		if m == nil {
+			// continuation lines are folded into the previous trailer
+			if len(trailers) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+				trailers[len(trailers)-1].Value += " " + strings.TrimSpace(line)
+			}
+			continue
+		}
+		trailers = append(trailers, Trailer{Key: m[1], Value: strings.TrimSpace(m[2])})
+	}
+	return trailers
+}
+
+// TrailerValues returns the values of all trailers matching key. Keys are
+// compared case-insensitively.
+func (c *Commit) TrailerValues(key string) []string {
+	var vals []string
+	for _, t := range c.Trailers() {
+		if strings.EqualFold(t.Key, key) {
+			vals = append(vals, t.Value)
+		}
+	}
+	return vals
+}
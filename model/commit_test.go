@@ -10,3 +10,62 @@ func TestCommit(t *testing.T) {
 		t.Fatal("expected", expect, "got", short)
 	}
 }
+
+func TestCommitTrailers(t *testing.T) {
+	tcs := []struct {
+		name   string
+		body   string
+		key    string
+		expect []string
+	}{
+		{
+			name: "none",
+			body: "just a body",
+			key:  "Signed-off-by",
+		},
+		{
+			name:   "single",
+			body:   "a body\n\nSigned-off-by: Cool <cool@example.com>\n",
+			key:    "Signed-off-by",
+			expect: []string{"Cool <cool@example.com>"},
+		},
+		{
+			name:   "case-insensitive",
+			body:   "a body\n\nsigned-off-by: Cool <cool@example.com>",
+			key:    "Signed-off-by",
+			expect: []string{"Cool <cool@example.com>"},
+		},
+		{
+			name:   "multi",
+			body:   "Release: skip\nSigned-off-by: a\nSigned-off-by: b",
+			key:    "Signed-off-by",
+			expect: []string{"a", "b"},
+		},
+		{
+			name:   "continuation",
+			body:   "a body\n\nNote: first\n  second",
+			key:    "note",
+			expect: []string{"first second"},
+		},
+		{
+			name: "not-last-paragraph",
+			body: "Release: skip\n\nmore body",
+			key:  "Release",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			cmt := &Commit{Body: tc.body}
+			vals := cmt.TrailerValues(tc.key)
+			if len(vals) != len(tc.expect) {
+				t.Fatalf("expected %q, got %q", tc.expect, vals)
+			}
+			for i, val := range vals {
+				if val != tc.expect[i] {
+					t.Errorf("expected %q, got %q", tc.expect[i], val)
+				}
+			}
+		})
+	}
+}
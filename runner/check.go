@@ -9,7 +9,6 @@ import (
 	"strings"
 
 	"github.com/jeffrom/tunk/commit"
-	"github.com/jeffrom/tunk/config"
 	"github.com/jeffrom/tunk/model"
 )
 
@@ -91,7 +90,6 @@ func (cf CheckFailure) WriteFailure(w io.Writer) error {
 
 func (r *Runner) CheckCommits(ctx context.Context, commits []string) (commit.AnalyzedCommits, error) {
 	var failures []FailureEntry
-	policies := r.cfg.GetPolicies()
 	var acs commit.AnalyzedCommits
 	for _, c := range commits {
 		mc, err := r.parseCommit(c)
@@ -100,14 +98,14 @@ func (r *Runner) CheckCommits(ctx context.Context, commits []string) (commit.Ana
 			continue
 		}
 
-		ac, err := r.analyzer.Match(mc, policies)
+		ac, err := r.matchCommit(mc, r.cfg.Scope)
 		if err != nil {
 			failures = append(failures, FailureEntry{commitID: mc.ID, commitTitle: mc.Subject, err: err})
 			continue
 		}
 		acs = append(acs, ac)
 
-		failures = append(failures, r.checkCommit(ac, policies)...)
+		failures = append(failures, r.checkCommit(ctx, ac, c)...)
 	}
 	if len(failures) > 0 {
 		return nil, CheckFailure{Failures: failures}
@@ -115,20 +113,51 @@ func (r *Runner) CheckCommits(ctx context.Context, commits []string) (commit.Ana
 	return acs, nil
 }
 
-func (r *Runner) checkCommit(ac *commit.AnalyzedCommit, policies []*config.Policy) []FailureEntry {
+// matchCommit matches the commit against the policies of scope, or if scope
+// is empty, the policies of the scope the commit belongs to.
+func (r *Runner) matchCommit(mc *model.Commit, scope string) (*commit.AnalyzedCommit, error) {
+	if scope == "" {
+		return r.analyzer.MatchScope(mc)
+	}
+	sa, err := r.analyzer.ForScope(scope)
+	if err != nil {
+		return nil, err
+	}
+	return sa.Match(mc, sa.Policies())
+}
+
+func (r *Runner) checkCommit(ctx context.Context, ac *commit.AnalyzedCommit, raw string) []FailureEntry {
 	var failures []FailureEntry
+	if ac.Ignored {
+		return nil
+	}
+	scope := ac.Scope
+	if scope == "" {
+		scope = r.cfg.Scope
+	}
+	cfg := r.cfg.ForScope(scope)
 
 	// if !ac.Valid {
 	// 	failures = append(failures, FailureEntry{commitID: mc.ID, commitTitle: mc.Subject, err: errors.New("commit was invalid")})
 	// 	continue
 	// }
-	if ac.Scope != "" && len(r.cfg.AllowedScopes) > 0 && !inStrs(ac.Scope, r.cfg.AllowedScopes) {
-		failures = append(failures, FailureEntry{commitID: ac.ID, commitTitle: ac.Subject, err: fmt.Errorf("scope %q is disallowed", ac.Scope)})
+	for _, acScope := range ac.ScopeNames() {
+		if len(cfg.AllowedScopes) > 0 && !inStrs(acScope, cfg.AllowedScopes) && !r.isDetectedScope(ctx, acScope) {
+			failures = append(failures, FailureEntry{commitID: ac.ID, commitTitle: ac.Subject, err: fmt.Errorf("scope %q is disallowed", acScope)})
+		}
 	}
-	if ac.CommitType != "" && len(r.cfg.AllowedTypes) > 0 && !inStrs(ac.CommitType, r.cfg.AllowedTypes) {
+	if ac.CommitType != "" && len(cfg.AllowedTypes) > 0 && !inStrs(ac.CommitType, cfg.AllowedTypes) {
 		failures = append(failures, FailureEntry{commitID: ac.ID, commitTitle: ac.Subject, err: fmt.Errorf("commit type %q is disallowed", ac.CommitType)})
 	}
 
+	errs, warnings := r.lintCommit(ac, raw)
+	for _, err := range errs {
+		failures = append(failures, FailureEntry{commitID: ac.ID, commitTitle: ac.Subject, err: err})
+	}
+	for _, warning := range warnings {
+		r.cfg.Warning("%s: %v", ac.Subject, warning)
+	}
+
 	return failures
 }
 
@@ -187,16 +216,15 @@ func (r *Runner) CheckCommitsFromGit(ctx context.Context, scope string) (commit.
 	if err != nil {
 		return nil, err
 	}
-	policies := r.cfg.GetPolicies()
 	var failures []FailureEntry
 	var acs commit.AnalyzedCommits
 	for _, mc := range commits {
-		ac, err := r.analyzer.Match(mc, policies)
+		ac, err := r.matchCommit(mc, "")
 		if err != nil {
 			failures = append(failures, FailureEntry{commitID: mc.ID, commitTitle: mc.Subject, err: err})
 			continue
 		}
-		fs := r.checkCommit(ac, policies)
+		fs := r.checkCommit(ctx, ac, rawMessage(ac))
 		failures = append(failures, fs...)
 		acs = append(acs, ac)
 	}
@@ -207,6 +235,21 @@ func (r *Runner) CheckCommitsFromGit(ctx context.Context, scope string) (commit.
 	return acs, nil
 }
 
+// isDetectedScope returns true if scope has release tags. Tags that can't be
+// read, such as when checking a commit message outside of a repository, don't
+// fail the check.
+func (r *Runner) isDetectedScope(ctx context.Context, scope string) bool {
+	if r.cfg.NoDetectScopes {
+		return false
+	}
+	scopes, err := r.analyzer.DetectScopes(ctx)
+	if err != nil {
+		r.cfg.Debugf("detect scopes failed: %v", err)
+		return false
+	}
+	return inStrs(scope, scopes)
+}
+
 func inStrs(s string, cands []string) bool {
 	for _, cand := range cands {
 		if s == cand {
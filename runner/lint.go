@@ -0,0 +1,229 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jeffrom/tunk/commit"
+	"github.com/jeffrom/tunk/config"
+)
+
+const (
+	defaultSubjectMaxLength  = 72
+	defaultBodyMaxLineLength = 100
+)
+
+const scissorsLine = "# ------------------------ >8 ------------------------"
+
+type lintFunc func(msg *lintMessage, rule *lintRule) error
+
+// lintRule is a configured lint rule, prepared when the runner is created.
+type lintRule struct {
+	config.LintRule
+	// words are the patterns of forbidden_words.
+	words []*regexp.Regexp
+}
+
+func newLintRules(rules map[string]config.LintRule) map[string]*lintRule {
+	res := make(map[string]*lintRule, len(rules))
+	for name, rule := range rules {
+		lr := &lintRule{LintRule: rule}
+		if name == config.LintForbiddenWords {
+			for _, word := range rule.Values {
+				lr.words = append(lr.words, forbiddenWordRE(word))
+			}
+		}
+		res[name] = lr
+	}
+	return res
+}
+
+// forbiddenWordRE matches word case-insensitively as a whole word. Words that
+// start or end with punctuation, such as "fixup!" or "[skip ci]", are
+// delimited by non-word characters instead of word boundaries.
+func forbiddenWordRE(word string) *regexp.Regexp {
+	start, end := `\b`, `\b`
+	if word != "" && !isWordByte(word[0]) {
+		start = `(?:^|\W)`
+	}
+	if word != "" && !isWordByte(word[len(word)-1]) {
+		end = `(?:\W|$)`
+	}
+	return regexp.MustCompile(`(?im)` + start + regexp.QuoteMeta(word) + end)
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || ('0' <= c && c <= '9') || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+var lintFuncs = map[string]lintFunc{
+	config.LintSubjectMaxLength:        lintSubjectMaxLength,
+	config.LintSubjectNoTrailingPeriod: lintSubjectNoTrailingPeriod,
+	config.LintTypeLowerCase:           lintTypeLowerCase,
+	config.LintBodyLeadingBlank:        lintBodyLeadingBlank,
+	config.LintBodyMaxLineLength:       lintBodyMaxLineLength,
+	config.LintRequiredTrailers:        lintRequiredTrailers,
+	config.LintForbiddenWords:          lintForbiddenWords,
+}
+
+// lintMessage is a commit message prepared for linting. lines contains the
+// raw message lines, without comments.
+type lintMessage struct {
+	ac    *commit.AnalyzedCommit
+	lines []string
+}
+
+func newLintMessage(ac *commit.AnalyzedCommit, raw string) *lintMessage {
+	var lines []string
+	for _, line := range strings.Split(raw, "\n") {
+		if line == scissorsLine {
+			break
+		}
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return &lintMessage{ac: ac, lines: lines}
+}
+
+// rawMessage returns the commit message as it was read from git, or rebuilds
+// it when only the subject and body were read.
+func rawMessage(ac *commit.AnalyzedCommit) string {
+	if ac.Message != "" {
+		return ac.Message
+	}
+	if ac.Body == "" {
+		return ac.Subject
+	}
+	return ac.Subject + "\n\n" + ac.Body
+}
+
+func (m *lintMessage) subject() string {
+	if len(m.lines) == 0 {
+		return ""
+	}
+	return m.lines[0]
+}
+
+func (m *lintMessage) body() []string {
+	if len(m.lines) < 2 {
+		return nil
+	}
+	return m.lines[1:]
+}
+
+// lintCommit checks the commit message against the configured lint rules,
+// returning violations of error rules and warning rules separately.
+func (r *Runner) lintCommit(ac *commit.AnalyzedCommit, raw string) ([]error, []error) {
+	if len(r.lint) == 0 {
+		return nil, nil
+	}
+	msg := newLintMessage(ac, raw)
+	var errs, warnings []error
+	for _, name := range config.LintRuleNames {
+		rule, ok := r.lint[name]
+		if !ok {
+			continue
+		}
+		err := lintFuncs[name](msg, rule)
+		if err == nil {
+			continue
+		}
+		err = fmt.Errorf("%s: %w", name, err)
+		if rule.IsWarning() {
+			warnings = append(warnings, err)
+		} else {
+			errs = append(errs, err)
+		}
+	}
+	return errs, warnings
+}
+
+func lintSubjectMaxLength(msg *lintMessage, rule *lintRule) error {
+	max := rule.Max
+	if max == 0 {
+		max = defaultSubjectMaxLength
+	}
+	if n := utf8.RuneCountInString(msg.subject()); n > max {
+		return fmt.Errorf("subject is %d characters, the maximum is %d", n, max)
+	}
+	return nil
+}
+
+func lintSubjectNoTrailingPeriod(msg *lintMessage, rule *lintRule) error {
+	if strings.HasSuffix(strings.TrimSpace(msg.subject()), ".") {
+		return errors.New("subject must not end with a period")
+	}
+	return nil
+}
+
+// subjectTypeRE reads the commit type from subjects that policies didn't read
+// one from, such as "Feat: subject", which is lax policy's scope syntax.
+var subjectTypeRE = regexp.MustCompile(`^([A-Za-z]+)(?:\([^)]*\))?!?: `)
+
+func lintTypeLowerCase(msg *lintMessage, rule *lintRule) error {
+	t := msg.ac.CommitType
+	if t == "" {
+		if match := subjectTypeRE.FindStringSubmatch(msg.subject()); match != nil {
+			t = match[1]
+		}
+	}
+	if t != strings.ToLower(t) {
+		return fmt.Errorf("commit type %q must be lower case", t)
+	}
+	return nil
+}
+
+func lintBodyLeadingBlank(msg *lintMessage, rule *lintRule) error {
+	if body := msg.body(); len(body) > 0 && strings.TrimSpace(body[0]) != "" {
+		return errors.New("body must be separated from the subject by a blank line")
+	}
+	return nil
+}
+
+func lintBodyMaxLineLength(msg *lintMessage, rule *lintRule) error {
+	max := rule.Max
+	if max == 0 {
+		max = defaultBodyMaxLineLength
+	}
+	for i, line := range msg.body() {
+		if n := utf8.RuneCountInString(line); n > max {
+			return fmt.Errorf("line %d is %d characters, the maximum is %d", i+2, n, max)
+		}
+	}
+	return nil
+}
+
+func lintRequiredTrailers(msg *lintMessage, rule *lintRule) error {
+	var missing []string
+	for _, key := range rule.Values {
+		if len(msg.ac.TrailerValues(key)) == 0 {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing trailer(s): %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+func lintForbiddenWords(msg *lintMessage, rule *lintRule) error {
+	text := strings.Join(msg.lines, "\n")
+	var found []string
+	for i, re := range rule.words {
+		if re.MatchString(text) {
+			found = append(found, rule.Values[i])
+		}
+	}
+	if len(found) > 0 {
+		return fmt.Errorf("forbidden word(s): %s", strings.Join(found, ", "))
+	}
+	return nil
+}
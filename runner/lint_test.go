@@ -0,0 +1,164 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jeffrom/tunk/config"
+	"github.com/jeffrom/tunk/vcs"
+)
+
+func TestLintRules(t *testing.T) {
+	tcs := []struct {
+		name    string
+		lint    map[string]config.LintRule
+		message string
+		expect  []string
+	}{
+		{
+			name:    "subject-max-length",
+			lint:    map[string]config.LintRule{config.LintSubjectMaxLength: {Max: 20}},
+			message: "feat: this subject is too long",
+			expect:  []string{"subject_max_length: subject is 30 characters, the maximum is 20"},
+		},
+		{
+			name:    "subject-max-length-default",
+			lint:    map[string]config.LintRule{config.LintSubjectMaxLength: {}},
+			message: "feat: this subject is fine",
+		},
+		{
+			name:    "subject-no-trailing-period",
+			lint:    map[string]config.LintRule{config.LintSubjectNoTrailingPeriod: {}},
+			message: "feat: cool feature.",
+			expect:  []string{"subject_no_trailing_period: subject must not end with a period"},
+		},
+		{
+			name:    "type-lower-case",
+			lint:    map[string]config.LintRule{config.LintTypeLowerCase: {}},
+			message: "Feat: cool feature",
+			expect:  []string{`type_lower_case: commit type "Feat" must be lower case`},
+		},
+		{
+			name:    "body-leading-blank",
+			lint:    map[string]config.LintRule{config.LintBodyLeadingBlank: {}},
+			message: "feat: cool feature\nno blank line",
+			expect:  []string{"body_leading_blank: body must be separated from the subject by a blank line"},
+		},
+		{
+			name:    "body-max-line-length",
+			lint:    map[string]config.LintRule{config.LintBodyMaxLineLength: {Max: 10}},
+			message: "feat: cool feature\n\nshort\nthis line is too long",
+			expect:  []string{"body_max_line_length: line 4 is 21 characters, the maximum is 10"},
+		},
+		{
+			name:    "required-trailers",
+			lint:    map[string]config.LintRule{config.LintRequiredTrailers: {Values: []string{"Signed-off-by", "Refs"}}},
+			message: "feat: cool feature\n\nSigned-off-by: Jeff <jeff@example.com>",
+			expect:  []string{"required_trailers: missing trailer(s): Refs"},
+		},
+		{
+			name:    "forbidden-words",
+			lint:    map[string]config.LintRule{config.LintForbiddenWords: {Values: []string{"wip", "fixup"}}},
+			message: "feat: cool feature\n\nstill WIP",
+			expect:  []string{"forbidden_words: forbidden word(s): wip"},
+		},
+		{
+			name:    "forbidden-words-punctuation",
+			lint:    map[string]config.LintRule{config.LintForbiddenWords: {Values: []string{"fixup!", "WIP:", "[skip ci]"}}},
+			message: "feat: cool feature\n\nwip: not done [skip ci]\nfixup! later",
+			expect:  []string{"forbidden_words: forbidden word(s): fixup!, WIP:, [skip ci]"},
+		},
+		{
+			name:    "forbidden-words-punctuation-partial",
+			lint:    map[string]config.LintRule{config.LintForbiddenWords: {Values: []string{"fixup!", "WIP:", "[skip ci]"}}},
+			message: "feat: cool feature\n\nunwip: a fixup!s x[skip ci]y",
+		},
+		{
+			name:    "comments",
+			lint:    map[string]config.LintRule{config.LintForbiddenWords: {Values: []string{"wip"}}, config.LintBodyLeadingBlank: {}},
+			message: "feat: cool feature\n# wip\n\n" + scissorsLine + "\nwip",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := config.New(&config.Config{Lint: tc.lint})
+			if err := cfg.Validate(); err != nil {
+				t.Fatal(err)
+			}
+			rnr, err := New(cfg, vcs.NewMock())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			mc, err := rnr.parseCommit(tc.message)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ac, err := rnr.matchCommit(mc, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			errs, warnings := rnr.lintCommit(ac, tc.message)
+			if len(warnings) > 0 {
+				t.Errorf("expected no warnings, got %v", warnings)
+			}
+			var got []string
+			for _, err := range errs {
+				got = append(got, err.Error())
+			}
+			if strings.Join(got, "\n") != strings.Join(tc.expect, "\n") {
+				t.Errorf("expected errors:\n%q\ngot:\n%q", tc.expect, got)
+			}
+		})
+	}
+}
+
+func TestLintWarning(t *testing.T) {
+	ob, eb := &bytes.Buffer{}, &bytes.Buffer{}
+	cfg := config.NewWithTerminalIO(&config.Config{
+		Lint: map[string]config.LintRule{
+			config.LintSubjectNoTrailingPeriod: {Severity: config.SeverityWarning},
+			config.LintSubjectMaxLength:        {Max: 10},
+		},
+	}, &config.TerminalIO{Stdout: ob, Stderr: eb})
+	rnr, err := New(cfg, vcs.NewMock())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := rnr.CheckCommits(context.Background(), []string{"fix: ok."}); err != nil {
+		t.Fatalf("expected warnings not to fail, got %v", err)
+	}
+	if !strings.Contains(eb.String(), "WARNING: fix: ok.: subject_no_trailing_period") {
+		t.Errorf("expected warning, got %q", eb.String())
+	}
+
+	_, err = rnr.CheckCommits(context.Background(), []string{"fix: too long"})
+	if !errors.Is(err, CheckFailure{}) {
+		t.Fatalf("expected check failure, got %v", err)
+	}
+}
+
+func TestValidateLintRules(t *testing.T) {
+	tcs := []struct {
+		name string
+		lint map[string]config.LintRule
+	}{
+		{name: "unknown", lint: map[string]config.LintRule{"nope": {}}},
+		{name: "severity", lint: map[string]config.LintRule{config.LintSubjectMaxLength: {Severity: "fatal"}}},
+		{name: "values", lint: map[string]config.LintRule{config.LintForbiddenWords: {}}},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := config.New(&config.Config{Lint: tc.lint})
+			if err := cfg.Validate(); err == nil {
+				t.Fatal("expected invalid lint config")
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package runner
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ghodss/yaml"
+
+	"github.com/jeffrom/tunk/commit"
+)
+
+// PolicyFixture is a commit message and the expected result of matching it
+// against the configured policies. A fixture with no policy and no release
+// type expects that no policy matches the commit.
+type PolicyFixture struct {
+	Message     string `json:"message"`
+	Policy      string `json:"policy,omitempty"`
+	ReleaseType string `json:"release_type,omitempty"`
+	Scope       string `json:"scope,omitempty"`
+	CommitType  string `json:"commit_type,omitempty"`
+	Breaking    bool   `json:"breaking,omitempty"`
+}
+
+// ReadPolicyFixtures reads a YAML list of policy fixtures.
+func ReadPolicyFixtures(r io.Reader) ([]PolicyFixture, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var fixtures []PolicyFixture
+	if err := yaml.Unmarshal(b, &fixtures); err != nil {
+		return nil, err
+	}
+	for i, f := range fixtures {
+		if strings.TrimSpace(f.Message) == "" {
+			return nil, fmt.Errorf("policy fixture #%d: message is required", i+1)
+		}
+	}
+	return fixtures, nil
+}
+
+type PolicyTestResult struct {
+	Fixture PolicyFixture
+	Got     PolicyFixture
+	Diffs   []string
+}
+
+func (r PolicyTestResult) Passed() bool { return len(r.Diffs) == 0 }
+
+type PolicyTestReport struct {
+	Results []PolicyTestResult
+}
+
+func (rep *PolicyTestReport) Failed() int {
+	n := 0
+	for _, res := range rep.Results {
+		if !res.Passed() {
+			n++
+		}
+	}
+	return n
+}
+
+func (rep *PolicyTestReport) TextSummary(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	for _, res := range rep.Results {
+		status := "PASS"
+		if !res.Passed() {
+			status = "FAIL"
+		}
+		bw.WriteString(fmt.Sprintf("%s %s\n", status, strings.SplitN(res.Fixture.Message, "\n", 2)[0]))
+		for _, diff := range res.Diffs {
+			bw.WriteString(diff)
+			bw.WriteString("\n")
+		}
+	}
+
+	failed := rep.Failed()
+	bw.WriteString(fmt.Sprintf("\n%d fixture(s), %d passed, %d failed\n", len(rep.Results), len(rep.Results)-failed, failed))
+	return bw.Flush()
+}
+
+// TestPolicies matches each fixture's commit message against the configured
+// policies, including those of the commit's scope, and compares the result to
+// the fixture's expectations.
+func (r *Runner) TestPolicies(ctx context.Context, fixtures []PolicyFixture) (*PolicyTestReport, error) {
+	rep := &PolicyTestReport{}
+	for _, fixture := range fixtures {
+		mc, err := r.parseCommit(strings.TrimRight(fixture.Message, "\n"))
+		if err != nil {
+			return nil, err
+		}
+
+		got := PolicyFixture{Message: fixture.Message}
+		// fixtures are matched as --check matches commits, using the
+		// policies of the commit's scope.
+		ac, err := r.matchCommit(mc, r.cfg.Scope)
+		if err != nil && !errors.Is(err, commit.NoMatchingPolicyError{}) {
+			return nil, err
+		}
+		if ac != nil {
+			if ac.Policy != nil {
+				got.Policy = ac.Policy.Name
+			}
+			got.ReleaseType = ac.ReleaseType.String()
+			got.Scope = strings.Join(ac.ScopeNames(), r.cfg.ScopeSeparator)
+			got.CommitType = ac.CommitType
+			got.Breaking = ac.Breaking
+		}
+
+		rep.Results = append(rep.Results, PolicyTestResult{
+			Fixture: fixture,
+			Got:     got,
+			Diffs:   diffPolicyFixtures(fixture, got),
+		})
+	}
+	return rep, nil
+}
+
+func diffPolicyFixtures(expect, got PolicyFixture) []string {
+	var diffs []string
+	diff := func(name, expect, got string) {
+		if expect == got {
+			return
+		}
+		diffs = append(diffs, fmt.Sprintf("  - %s: %q\n  + %s: %q", name, expect, name, got))
+	}
+	diff("policy", expect.Policy, got.Policy)
+	diff("release_type", expect.ReleaseType, got.ReleaseType)
+	diff("scope", expect.Scope, got.Scope)
+	diff("commit_type", expect.CommitType, got.CommitType)
+	diff("breaking", fmt.Sprint(expect.Breaking), fmt.Sprint(got.Breaking))
+	return diffs
+}
@@ -0,0 +1,117 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jeffrom/tunk/config"
+	"github.com/jeffrom/tunk/vcs"
+)
+
+const testPolicyFixtures = `
+- message: "feat(api): cool feature"
+  policy: conventional-lax
+  release_type: MINOR
+  scope: api
+  commit_type: feat
+- message: |
+    fix: cool fix
+
+    BREAKING CHANGE: not so cool
+  policy: conventional-lax
+  release_type: MAJOR
+  commit_type: fix
+  breaking: true
+- message: "cool: thing"
+  policy: lax
+  release_type: PATCH
+  scope: cool
+- message: "chore: wrong"
+  policy: conventional-lax
+  release_type: PATCH
+  commit_type: chore
+`
+
+func TestPolicyTest(t *testing.T) {
+	fixtures, err := ReadPolicyFixtures(strings.NewReader(testPolicyFixtures))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fixtures) != 4 {
+		t.Fatalf("expected 4 fixtures, got %d", len(fixtures))
+	}
+
+	cfg := config.NewWithTerminalIO(nil, &config.TerminalIO{Stdout: &bytes.Buffer{}, Stderr: &bytes.Buffer{}})
+	rnr, err := New(cfg, vcs.NewMock())
+	if err != nil {
+		t.Fatal(err)
+	}
+	rep, err := rnr.TestPolicies(context.Background(), fixtures)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := &bytes.Buffer{}
+	if err := rep.TextSummary(b); err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("report:\n%s", b.String())
+
+	if failed := rep.Failed(); failed != 1 {
+		t.Fatalf("expected 1 failure, got %d", failed)
+	}
+	res := rep.Results[3]
+	if res.Passed() {
+		t.Fatal("expected last fixture to fail")
+	}
+	if len(res.Diffs) != 1 || !strings.Contains(res.Diffs[0], `+ release_type: "SKIP"`) {
+		t.Errorf("expected release type diff, got %q", res.Diffs)
+	}
+}
+
+func TestPolicyTestNoMatch(t *testing.T) {
+	cfg := config.NewWithTerminalIO(&config.Config{Policies: []string{"conventional"}}, &config.TerminalIO{Stdout: &bytes.Buffer{}, Stderr: &bytes.Buffer{}})
+	rnr, err := New(cfg, vcs.NewMock())
+	if err != nil {
+		t.Fatal(err)
+	}
+	rep, err := rnr.TestPolicies(context.Background(), []PolicyFixture{{Message: "not conventional"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if failed := rep.Failed(); failed != 0 {
+		t.Fatalf("expected no failures, got %d: %q", failed, rep.Results[0].Diffs)
+	}
+}
+
+func TestPolicyTestScopePolicies(t *testing.T) {
+	cfg := config.NewWithTerminalIO(&config.Config{
+		Policies: []string{"conventional"},
+		Scopes:   map[string]config.ScopeConfig{"api": {Policies: []string{"angular"}}},
+	}, &config.TerminalIO{Stdout: &bytes.Buffer{}, Stderr: &bytes.Buffer{}})
+	rnr, err := New(cfg, vcs.NewMock())
+	if err != nil {
+		t.Fatal(err)
+	}
+	fixtures := []PolicyFixture{
+		{Message: "feat(api): cool feature", Policy: "angular", ReleaseType: "MINOR", Scope: "api", CommitType: "feat"},
+		{Message: "feat(cli): cool feature", Policy: "conventional", ReleaseType: "MINOR", Scope: "cli", CommitType: "feat"},
+	}
+	rep, err := rnr.TestPolicies(context.Background(), fixtures)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, res := range rep.Results {
+		if !res.Passed() {
+			t.Errorf("expected %q to pass, got %q", res.Fixture.Message, res.Diffs)
+		}
+	}
+}
+
+func TestReadPolicyFixturesInvalid(t *testing.T) {
+	if _, err := ReadPolicyFixtures(strings.NewReader(`[{policy: lax}]`)); err == nil {
+		t.Fatal("expected missing message error")
+	}
+}
@@ -20,36 +20,51 @@ type Runner struct {
 	analyzer   *commit.Analyzer
 	tag        *commit.Tag
 	mainBranch string
+	// releaseBranch is set when releasing from a maintenance branch.
+	releaseBranch string
+	// aliases are the alias tags moved by CreateTags.
+	aliases []string
+	// promoted is set when releasing a promoted release candidate, which is
+	// tagged on the release candidate's commit as it is.
+	promoted bool
+	// lint are the configured lint rules.
+	lint map[string]*lintRule
 }
 
 func New(cfg config.Config, vcs vcs.Interface) (*Runner, error) {
-	tag, err := commit.NewTag(cfg.TagTemplate)
+	tag, err := commit.NewTagFromConfig(cfg)
 	if err != nil {
 		return nil, err
 	}
+	analyzer := commit.NewAnalyzer(cfg, vcs, tag)
+	for scope := range cfg.Scopes {
+		if _, err := analyzer.ForScope(scope); err != nil {
+			return nil, err
+		}
+	}
 	return &Runner{
 		cfg:      cfg,
 		vcs:      vcs,
 		tag:      tag,
-		analyzer: commit.NewAnalyzer(cfg, vcs, tag),
+		analyzer: analyzer,
+		lint:     newLintRules(cfg.Lint),
 	}, nil
 }
 
 // Check checks initial requirements for release, such as being on the right branch.
 func (r *Runner) Check(ctx context.Context, rc string) error {
-	// TODO detect scopes from tags (check first release_scopes /
-	// allowed_scopes, and fall back to detection) and check them all
 	if r.mainBranch == "" {
-		branches := r.cfg.Branches
-		if r.cfg.InCI && !r.cfg.BranchesSet {
+		cfg := r.cfg.ForScope(r.cfg.Scope)
+		branches := cfg.Branches
+		if cfg.InCI && !cfg.BranchesSet {
 			branches = nil
 		}
 		var mainBranch string
 		var err error
 		mainBranch, err = r.vcs.GetMainBranch(ctx, branches)
 		if err != nil {
-			r.cfg.Printf("Get remote failed, falling back to defaults: %v", r.cfg.Branches)
-			mainBranch, err = r.vcs.GetMainBranch(ctx, r.cfg.Branches)
+			r.cfg.Printf("Get remote failed, falling back to defaults: %v", cfg.Branches)
+			mainBranch, err = r.vcs.GetMainBranch(ctx, cfg.Branches)
 			if err != nil {
 				return err
 			}
@@ -62,6 +77,11 @@ func (r *Runner) Check(ctx context.Context, rc string) error {
 	if err != nil {
 		return err
 	}
+	if currBranch != r.mainBranch && r.cfg.IsMaintenanceBranch(currBranch) {
+		r.releaseBranch = currBranch
+		r.cfg.Printf("Maintenance branch is %q", currBranch)
+		return nil
+	}
 	if currBranch != r.mainBranch && !r.cfg.Dryrun {
 		return wrongBranchError{mainBranch: r.mainBranch, branch: currBranch}
 	}
@@ -72,10 +92,35 @@ func (r *Runner) Analyze(ctx context.Context, rc string) ([]*commit.Version, err
 	return r.analyzer.Analyze(ctx, rc)
 }
 
+// Promote returns the final release of the release candidate tagged rcTag, or
+// the latest release candidate if rcTag is empty.
+func (r *Runner) Promote(ctx context.Context, rcTag string) (*commit.Version, error) {
+	ver, err := r.analyzer.Promote(ctx, r.cfg.Scope, rcTag)
+	if err != nil {
+		return nil, err
+	}
+	r.promoted = true
+	return ver, nil
+}
+
 func (r *Runner) LatestRelease(ctx context.Context, scope, rc string) (semver.Version, error) {
 	return r.analyzer.LatestRelease(ctx, scope, rc)
 }
 
+// LatestReleaseTag returns the latest release tag and its version.
+func (r *Runner) LatestReleaseTag(ctx context.Context, scope, rc string) (string, semver.Version, error) {
+	return r.analyzer.LatestReleaseTag(ctx, scope, rc)
+}
+
+// BuildMetadata returns the build metadata of a version of the scope.
+func (r *Runner) BuildMetadata(scope string, v semver.Version) ([]string, error) {
+	t, err := tagForScope(r.cfg, r.tag, scope)
+	if err != nil {
+		return nil, err
+	}
+	return t.BuildMetadata(v), nil
+}
+
 func (r *Runner) CreateTags(ctx context.Context, versions []*commit.Version) error {
 	name := r.cfg.Name
 	if name == "" {
@@ -85,7 +130,6 @@ func (r *Runner) CreateTags(ctx context.Context, versions []*commit.Version) err
 			return err
 		}
 	}
-
 	for _, ver := range versions {
 		opts := vcs.TagOpts{}
 		tag, err := RenderTag(r.cfg, r.tag, ver)
@@ -109,21 +153,60 @@ func (r *Runner) CreateTags(ctx context.Context, versions []*commit.Version) err
 		if err := r.vcs.CreateTag(ctx, ver.Commit, tag, opts); err != nil {
 			return err
 		}
+
+		aliases, err := r.analyzer.AliasTags(ctx, ver)
+		if err != nil {
+			return err
+		}
+		for _, alias := range aliases {
+			r.cfg.Printf("moving tag %q to %s...", alias, tag)
+			aliasOpts := vcs.TagOpts{Message: tag, Force: true, NoEdit: true}
+			if err := r.vcs.CreateTag(ctx, ver.Commit, alias, aliasOpts); err != nil {
+				return err
+			}
+			r.aliases = append(r.aliases, alias)
+		}
 	}
 	return nil
 }
 
 func (r *Runner) PushTags(ctx context.Context) error {
-	if err := r.vcs.Push(ctx, "origin", r.mainBranch, vcs.PushOpts{FollowTags: true}); err != nil {
+	branch := r.mainBranch
+	if r.releaseBranch != "" {
+		branch = r.releaseBranch
+	}
+	if err := r.vcs.Push(ctx, "origin", branch, vcs.PushOpts{FollowTags: true}); err != nil {
 		return err
 	}
+	// alias tags have moved, so they're pushed separately with force.
+	for _, alias := range r.aliases {
+		if err := r.vcs.Push(ctx, "origin", "refs/tags/"+alias, vcs.PushOpts{Force: true}); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// RenderTag renders the tag for the version. If the version's scope has its
+// own configuration, its tag template and version scheme are used instead of
+// t's.
 func RenderTag(cfg config.Config, t *commit.Tag, ver *commit.Version) (string, error) {
+	t, err := tagForScope(cfg, t, ver.Scope)
+	if err != nil {
+		return "", err
+	}
 	return t.ExecuteString(commit.TagData{Version: ver})
 }
 
+// tagForScope returns the tag of the scope's releases, which is t unless the
+// scope has its own configuration.
+func tagForScope(cfg config.Config, t *commit.Tag, scope string) (*commit.Tag, error) {
+	if cfg.HasScopeConfig(scope) {
+		return commit.NewTagFromConfig(cfg.ForScope(scope))
+	}
+	return t, nil
+}
+
 type wrongBranchError struct {
 	mainBranch string
 	branch     string
@@ -14,6 +14,9 @@ This release contains the following commits:
 {{ range $commit := .Version.ScopedCommits }}
 * {{ $commit.Subject }} ({{ $commit.ShortID }})
 {{ end }}
+{{- range $dep := .Version.Dependencies }}
+* dependency {{ $dep.Scope }} released v{{ $dep.Version }}
+{{ end }}
 {{ messageInfo }}
 `
 
@@ -40,8 +43,8 @@ func (r *Runner) shortlog(ctx context.Context, w io.Writer, ver *commit.Version,
 		return nil
 	}
 	tmpl := defaultShortlogTemplate
-	if r.cfg.LogTemplate != "" {
-		tmpl = r.cfg.LogTemplate
+	if logTmpl := r.cfg.ForScope(ver.Scope).LogTemplate; logTmpl != "" {
+		tmpl = logTmpl
 	}
 	t, err := template.New("shortlog").Funcs(funcMap).Parse(tmpl)
 	if err != nil {
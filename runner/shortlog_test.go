@@ -94,3 +94,38 @@ This release contains the following commits:
 		t.Fatalf("expected prefix:\n\t%q\ngot:\n\t%q", expectPrefix, res)
 	}
 }
+
+func TestShortlogDependencies(t *testing.T) {
+	cfg := config.New(&config.Config{Scope: "cli"})
+	git := gitcli.New(cfg, "")
+	rnr, err := New(cfg, git)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := &bytes.Buffer{}
+
+	ver := &commit.Version{
+		Version: semver.Version{Major: 2, Minor: 0, Patch: 1},
+		Scope:   "cli",
+		Dependencies: []*commit.Version{
+			{Version: semver.Version{Major: 1, Minor: 5, Patch: 0}, Scope: "sdk"},
+		},
+	}
+	if err := rnr.shortlog(context.Background(), b, ver, "test"); err != nil {
+		t.Fatal(err)
+	}
+
+	res := b.String()
+	expectPrefix := `cli: v2.0.1
+
+This release contains the following commits:
+
+* dependency sdk released v1.5.0
+
+# Please enter`
+
+	if !strings.HasPrefix(res, expectPrefix) {
+		t.Fatalf("expected prefix:\n\t%q\ngot:\n\t%q", expectPrefix, res)
+	}
+}
@@ -123,13 +123,18 @@ func (r *Runner) Stats(ctx context.Context) (*Stats, error) {
 		Counts:  make(map[string][]*statCount),
 	}
 
-	policies := r.cfg.GetPolicies()
 	for _, c := range commits {
-		ac, err := r.analyzer.Match(c, policies)
+		ac, err := r.analyzer.MatchScope(c)
 		if err != nil {
 			return nil, err
 		}
-		stats.Add("scope", ac.Scope, 1)
+		if scopes := ac.ScopeNames(); len(scopes) > 0 {
+			for _, scope := range scopes {
+				stats.Add("scope", scope, 1)
+			}
+		} else {
+			stats.Add("scope", "", 1)
+		}
 		stats.Add("commit_type", ac.CommitType, 1)
 		stats.Add("type", ac.ReleaseType.String(), 1)
 	}
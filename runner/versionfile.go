@@ -0,0 +1,318 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/jeffrom/tunk/commit"
+	"github.com/jeffrom/tunk/config"
+)
+
+// UpdateVersionFiles writes the versions to their scopes' version files and
+// commits them on HEAD, so the versions are tagged on the release commit
+// instead of the commits they were analyzed from. It must be called before
+// CreateTags. Promoted release candidates are tagged as they are. In dry-run
+// mode, the changes are printed as a diff instead.
+func (r *Runner) UpdateVersionFiles(ctx context.Context, versions []*commit.Version) error {
+	var paths []string
+	var tags []string
+	var released []*commit.Version
+	var withFiles []*commit.Version
+	for _, ver := range versions {
+		if len(r.cfg.VersionFilesFor(ver.Scope)) > 0 {
+			withFiles = append(withFiles, ver)
+		}
+	}
+	if len(withFiles) == 0 {
+		return nil
+	}
+	if r.promoted {
+		r.cfg.Printf("skipping version files, promoted releases are tagged on the release candidate's commit")
+		return nil
+	}
+	root, err := r.vcs.Root(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, ver := range withFiles {
+		files := r.cfg.VersionFilesFor(ver.Scope)
+		t, err := tagForScope(r.cfg, r.tag, ver.Scope)
+		if err != nil {
+			return err
+		}
+		tag, err := t.ExecuteString(commit.TagData{Version: ver})
+		if err != nil {
+			return err
+		}
+		version := t.FormatVersion(ver)
+
+		changed := false
+		for _, f := range files {
+			p := f.Path
+			if !filepath.IsAbs(p) {
+				p = filepath.Join(root, p)
+			}
+			ok, err := r.updateVersionFile(f, p, version)
+			if err != nil {
+				return fmt.Errorf("version file %s: %w", f.Path, err)
+			}
+			if ok {
+				paths = append(paths, p)
+				changed = true
+			}
+		}
+		if changed {
+			tags = append(tags, tag)
+			released = append(released, ver)
+		}
+	}
+	if len(paths) == 0 {
+		return nil
+	}
+
+	// the trailer keeps later runs from releasing the release commit itself.
+	tagList := strings.Join(tags, ", ")
+	msg := fmt.Sprintf("chore: release %s\n\n%s: %s", tagList, commit.ReleaseCommitTrailer, tagList)
+	id, err := r.vcs.Commit(ctx, msg, paths)
+	if err != nil {
+		return err
+	}
+	if r.cfg.Dryrun {
+		return nil
+	}
+	r.cfg.Printf("created release commit %s", shortID(id))
+	for _, ver := range released {
+		ver.Commit = id
+	}
+	return nil
+}
+
+// updateVersionFile writes the version to the file at p, returning false if
+// it already contained the version.
+func (r *Runner) updateVersionFile(f config.VersionFile, p, version string) (bool, error) {
+	info, err := os.Stat(p)
+	if err != nil {
+		return false, err
+	}
+	b, err := os.ReadFile(p)
+	if err != nil {
+		return false, err
+	}
+
+	var updated []byte
+	switch f.Format {
+	case config.VersionFileJSON:
+		updated, err = setJSONVersion(b, f.Key, version)
+	case config.VersionFileTOML:
+		updated, err = setTOMLVersion(b, f.Key, version)
+	case config.VersionFileRegex:
+		updated, err = setRegexVersion(b, f.Pattern, version)
+	default:
+		err = fmt.Errorf("invalid format %q", f.Format)
+	}
+	if err != nil {
+		return false, err
+	}
+	if bytes.Equal(b, updated) {
+		return false, nil
+	}
+
+	if r.cfg.Dryrun {
+		if !r.cfg.Quiet {
+			writeDiff(r.cfg.Term.Stdout, f.Path, b, updated)
+		}
+		return true, nil
+	}
+	return true, os.WriteFile(p, updated, info.Mode())
+}
+
+// setJSONVersion replaces the string at the dot-separated key, leaving the
+// rest of the document as it was.
+func setJSONVersion(b []byte, key, version string) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	start, end, err := findJSONString(dec, b, strings.Split(key, "."))
+	if err != nil {
+		return nil, fmt.Errorf("key %q: %w", key, err)
+	}
+	val, err := json.Marshal(version)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]byte, 0, len(b)+len(val))
+	res = append(res, b[:start]...)
+	res = append(res, val...)
+	res = append(res, b[end:]...)
+	return res, nil
+}
+
+// findJSONString returns the offsets of the string value at path in the
+// object dec is about to read.
+func findJSONString(dec *json.Decoder, b []byte, path []string) (int, int, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return 0, 0, err
+	}
+	if tok != json.Delim('{') {
+		return 0, 0, fmt.Errorf("%q is not an object", path[0])
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return 0, 0, err
+		}
+		if tok != path[0] {
+			if err := skipJSONValue(dec); err != nil {
+				return 0, 0, err
+			}
+			continue
+		}
+		if len(path) > 1 {
+			return findJSONString(dec, b, path[1:])
+		}
+
+		offset := int(dec.InputOffset())
+		tok, err = dec.Token()
+		if err != nil {
+			return 0, 0, err
+		}
+		if _, ok := tok.(string); !ok {
+			return 0, 0, fmt.Errorf("%q is not a string", path[0])
+		}
+		end := int(dec.InputOffset())
+		start := offset + bytes.IndexByte(b[offset:end], '"')
+		return start, end, nil
+	}
+	return 0, 0, fmt.Errorf("%q not found", path[0])
+}
+
+func skipJSONValue(dec *json.Decoder) error {
+	depth := 0
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
+var (
+	tomlTableRE = regexp.MustCompile(`^\s*\[([^\[\]]+)\]\s*(?:#.*)?$`)
+	tomlKeyRE   = regexp.MustCompile(`^\s*([A-Za-z0-9_.-]+)\s*=\s*("[^"]*"|'[^']*')`)
+)
+
+// setTOMLVersion replaces the string value of the key. The key's last part is
+// looked up in the table named by the rest, such as "version" in [package] for
+// package.version, or as a dotted key at the top level.
+func setTOMLVersion(b []byte, key, version string) ([]byte, error) {
+	lines := strings.SplitAfter(string(b), "\n")
+	val, err := json.Marshal(version)
+	if err != nil {
+		return nil, err
+	}
+
+	table, name := "", key
+	if i := strings.LastIndex(key, "."); i >= 0 {
+		table, name = key[:i], key[i+1:]
+	}
+	if replaceTOMLKey(lines, table, name, string(val)) ||
+		(table != "" && replaceTOMLKey(lines, "", key, string(val))) {
+		return []byte(strings.Join(lines, "")), nil
+	}
+	return nil, fmt.Errorf("key %q not found", key)
+}
+
+func replaceTOMLKey(lines []string, table, name, val string) bool {
+	curr := ""
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "[[") {
+			// array tables can't hold the version.
+			curr = "[["
+			continue
+		}
+		if m := tomlTableRE.FindStringSubmatch(line); m != nil {
+			curr = strings.Join(strings.Fields(m[1]), "")
+			continue
+		}
+		if curr != table {
+			continue
+		}
+		if m := tomlKeyRE.FindStringSubmatchIndex(line); m != nil && line[m[2]:m[3]] == name {
+			lines[i] = line[:m[4]] + val + line[m[5]:]
+			return true
+		}
+	}
+	return false
+}
+
+// setRegexVersion replaces the first capture group of each match of pattern.
+func setRegexVersion(b []byte, pattern, version string) ([]byte, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	matches := re.FindAllSubmatchIndex(b, -1)
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("pattern %q not found", pattern)
+	}
+
+	var res []byte
+	prev := 0
+	for _, m := range matches {
+		if m[2] < 0 {
+			continue
+		}
+		res = append(res, b[prev:m[2]]...)
+		res = append(res, version...)
+		prev = m[3]
+	}
+	res = append(res, b[prev:]...)
+	return res, nil
+}
+
+// writeDiff writes the changed lines of a version file in unified diff
+// format.
+func writeDiff(w io.Writer, path string, before, after []byte) {
+	a := strings.Split(string(before), "\n")
+	b := strings.Split(string(after), "\n")
+	fmt.Fprintf(w, "--- a/%s\n+++ b/%s\n", path, path)
+	if len(a) != len(b) {
+		fmt.Fprintf(w, "@@ -1,%d +1,%d @@\n", len(a), len(b))
+		for _, line := range a {
+			fmt.Fprintf(w, "-%s\n", line)
+		}
+		for _, line := range b {
+			fmt.Fprintf(w, "+%s\n", line)
+		}
+		return
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			fmt.Fprintf(w, "@@ -%d +%d @@\n-%s\n+%s\n", i+1, i+1, a[i], b[i])
+		}
+	}
+}
+
+func shortID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
@@ -0,0 +1,335 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/blang/semver/v4"
+
+	"github.com/jeffrom/tunk/commit"
+	"github.com/jeffrom/tunk/config"
+	"github.com/jeffrom/tunk/vcs"
+	"github.com/jeffrom/tunk/vcs/gitcli"
+)
+
+func TestSetVersion(t *testing.T) {
+	tcs := []struct {
+		name       string
+		file       config.VersionFile
+		input      string
+		expect     string
+		shouldFail bool
+	}{
+		{
+			name:   "json",
+			file:   config.VersionFile{Format: config.VersionFileJSON, Key: "version"},
+			input:  "{\n  \"deps\": {\"version\": \"9.9.9\"},\n  \"list\": [1, {\"version\": \"2\"}],\n  \"version\" :  \"1.2.3\"\n}\n",
+			expect: "{\n  \"deps\": {\"version\": \"9.9.9\"},\n  \"list\": [1, {\"version\": \"2\"}],\n  \"version\" :  \"1.3.0\"\n}\n",
+		},
+		{
+			name:   "json-nested",
+			file:   config.VersionFile{Format: config.VersionFileJSON, Key: "tool.version"},
+			input:  `{"version": "0.0.0", "tool": {"name": "x", "version": "1.2.3"}}`,
+			expect: `{"version": "0.0.0", "tool": {"name": "x", "version": "1.3.0"}}`,
+		},
+		{
+			name:       "json-missing",
+			file:       config.VersionFile{Format: config.VersionFileJSON, Key: "version"},
+			input:      `{"name": "x"}`,
+			shouldFail: true,
+		},
+		{
+			name:       "json-not-string",
+			file:       config.VersionFile{Format: config.VersionFileJSON, Key: "version"},
+			input:      `{"version": 1}`,
+			shouldFail: true,
+		},
+		{
+			name:   "toml",
+			file:   config.VersionFile{Format: config.VersionFileTOML, Key: "package.version"},
+			input:  "[workspace]\nversion = \"0.0.0\"\n\n[package]\nname = \"x\"\nversion = '1.2.3' # cool\n",
+			expect: "[workspace]\nversion = \"0.0.0\"\n\n[package]\nname = \"x\"\nversion = \"1.3.0\" # cool\n",
+		},
+		{
+			name:   "toml-top-level",
+			file:   config.VersionFile{Format: config.VersionFileTOML, Key: "version"},
+			input:  "version = \"1.2.3\"\n\n[tool]\nversion = \"9.9.9\"\n",
+			expect: "version = \"1.3.0\"\n\n[tool]\nversion = \"9.9.9\"\n",
+		},
+		{
+			name:   "toml-dotted-key",
+			file:   config.VersionFile{Format: config.VersionFileTOML, Key: "tool.poetry.version"},
+			input:  "tool.poetry.version = \"1.2.3\"\n",
+			expect: "tool.poetry.version = \"1.3.0\"\n",
+		},
+		{
+			name:       "toml-missing",
+			file:       config.VersionFile{Format: config.VersionFileTOML, Key: "package.version"},
+			input:      "[[package]]\nversion = \"1.2.3\"\n",
+			shouldFail: true,
+		},
+		{
+			name:   "regex",
+			file:   config.VersionFile{Format: config.VersionFileRegex, Pattern: `const Version = "(.*)"`},
+			input:  "package main\n\nconst Version = \"1.2.3\"\n",
+			expect: "package main\n\nconst Version = \"1.3.0\"\n",
+		},
+		{
+			name:       "regex-missing",
+			file:       config.VersionFile{Format: config.VersionFileRegex, Pattern: `const Version = "(.*)"`},
+			input:      "package main\n",
+			shouldFail: true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			var res []byte
+			var err error
+			switch tc.file.Format {
+			case config.VersionFileJSON:
+				res, err = setJSONVersion([]byte(tc.input), tc.file.Key, "1.3.0")
+			case config.VersionFileTOML:
+				res, err = setTOMLVersion([]byte(tc.input), tc.file.Key, "1.3.0")
+			case config.VersionFileRegex:
+				res, err = setRegexVersion([]byte(tc.input), tc.file.Pattern, "1.3.0")
+			}
+			if tc.shouldFail {
+				if err == nil {
+					t.Fatalf("expected error, got %q", res)
+				}
+				t.Log(err)
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(res) != tc.expect {
+				t.Errorf("expected:\n%s\ngot:\n%s", tc.expect, res)
+			}
+		})
+	}
+}
+
+func TestUpdateVersionFiles(t *testing.T) {
+	dir := t.TempDir()
+	jsonPath := filepath.Join(dir, "package.json")
+	versionPath := filepath.Join(dir, "VERSION")
+	if err := os.WriteFile(jsonPath, []byte("{\"version\": \"1.2.3\"}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(versionPath, []byte("1.2.3\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, dryrun := range []bool{true, false} {
+		ob := &bytes.Buffer{}
+		cfg := config.NewWithTerminalIO(&config.Config{
+			Dryrun: dryrun,
+			VersionFiles: []config.VersionFile{
+				{Path: jsonPath, Format: config.VersionFileJSON, Key: "version"},
+				{Path: versionPath, Format: config.VersionFileRegex, Pattern: `\A(\S+)`},
+			},
+		}, &config.TerminalIO{Stdout: ob, Stderr: ob})
+		if err := cfg.Validate(); err != nil {
+			t.Fatal(err)
+		}
+		rnr, err := New(cfg, vcs.NewMock())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ver := &commit.Version{Version: semver.MustParse("1.3.0-rc.0"), Commit: "deadbeef"}
+		scoped := &commit.Version{Version: semver.MustParse("2.0.0"), Scope: "cool", Commit: "deadbeef"}
+		if err := rnr.UpdateVersionFiles(context.Background(), []*commit.Version{ver, scoped}); err != nil {
+			t.Fatal(err)
+		}
+		if scoped.Commit != "deadbeef" {
+			t.Errorf("expected scope without version files to keep its commit, got %q", scoped.Commit)
+		}
+
+		b, err := os.ReadFile(jsonPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if dryrun {
+			if ver.Commit != "deadbeef" {
+				t.Errorf("expected dry run to keep the commit, got %q", ver.Commit)
+			}
+			if string(b) != "{\"version\": \"1.2.3\"}\n" {
+				t.Errorf("expected dry run not to write files, got %q", b)
+			}
+			if !strings.Contains(ob.String(), "-{\"version\": \"1.2.3\"}\n+{\"version\": \"1.3.0-rc.0\"}") {
+				t.Errorf("expected diff, got:\n%s", ob.String())
+			}
+			continue
+		}
+
+		if ver.Commit != "feedface" {
+			t.Errorf("expected release commit %q, got %q", "feedface", ver.Commit)
+		}
+		if string(b) != "{\"version\": \"1.3.0-rc.0\"}\n" {
+			t.Errorf("expected version to be written, got %q", b)
+		}
+		if b, _ := os.ReadFile(versionPath); string(b) != "1.3.0-rc.0\n" {
+			t.Errorf("expected version to be written, got %q", b)
+		}
+	}
+}
+
+func TestUpdateVersionFilesReleaseCommit(t *testing.T) {
+	dir := t.TempDir()
+	versionPath := filepath.Join(dir, "VERSION")
+	if err := os.WriteFile(versionPath, []byte("1.2.3\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.NewWithTerminalIO(&config.Config{
+		VersionFiles: []config.VersionFile{{Path: versionPath, Format: config.VersionFileRegex, Pattern: `\A(\S+)`}},
+	}, &config.TerminalIO{Stdout: &bytes.Buffer{}, Stderr: &bytes.Buffer{}})
+
+	t.Run("scopes", func(t *testing.T) {
+		apiPath := filepath.Join(dir, "api.json")
+		if err := os.WriteFile(apiPath, []byte(`{"version": "0.4.0"}`+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		scfg := cfg
+		scfg.Scopes = map[string]config.ScopeConfig{
+			"api": {VersionFiles: []config.VersionFile{{Path: apiPath, Format: config.VersionFileJSON, Key: "version"}}},
+			"cli": {},
+		}
+		rnr, err := New(scfg, vcs.NewMock())
+		if err != nil {
+			t.Fatal(err)
+		}
+		// none of the scopes were last changed on HEAD.
+		vers := []*commit.Version{
+			{Version: semver.MustParse("1.3.0"), Commit: "cafebabe"},
+			{Version: semver.MustParse("0.5.0"), Scope: "api", Commit: "12345678"},
+			{Version: semver.MustParse("2.0.1"), Scope: "cli", Commit: "abcdef12"},
+		}
+		if err := rnr.UpdateVersionFiles(context.Background(), vers); err != nil {
+			t.Fatal(err)
+		}
+		for i, expect := range []string{"feedface", "feedface", "abcdef12"} {
+			if vers[i].Commit != expect {
+				t.Errorf("expected %s (scope: %q) to be tagged on %q, got %q", vers[i].Version, vers[i].Scope, expect, vers[i].Commit)
+			}
+		}
+		if b, _ := os.ReadFile(versionPath); string(b) != "1.3.0\n" {
+			t.Errorf("expected version file to be written, got %q", b)
+		}
+		if b, _ := os.ReadFile(apiPath); string(b) != `{"version": "0.5.0"}`+"\n" {
+			t.Errorf("expected scope's version file to be written, got %q", b)
+		}
+		if err := os.WriteFile(versionPath, []byte("1.2.3\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("promote", func(t *testing.T) {
+		m := vcs.NewMock().SetTags("v1.2.3", "v1.3.0-rc.0")
+		rnr, err := New(cfg, m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ver, err := rnr.Promote(context.Background(), "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		rcCommit := ver.Commit
+		if err := rnr.UpdateVersionFiles(context.Background(), []*commit.Version{ver}); err != nil {
+			t.Fatal(err)
+		}
+		if ver.Commit != rcCommit {
+			t.Errorf("expected promoted release to stay on %q, got %q", rcCommit, ver.Commit)
+		}
+		if b, _ := os.ReadFile(versionPath); string(b) != "1.2.3\n" {
+			t.Errorf("expected version file not to be written, got %q", b)
+		}
+	})
+}
+
+func TestUpdateVersionFilesSubdirectory(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+	dir := t.TempDir()
+	git := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %q: %v: %s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	git("init", "-q")
+	git("config", "user.email", "tunk-test@example.com")
+	git("config", "user.name", "tunk-test")
+	if err := os.WriteFile(filepath.Join(dir, "VERSION"), []byte("1.2.3\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "sub", "VERSION"), []byte("9.9.9\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	git("add", ".")
+	git("commit", "-q", "-m", "initial commit")
+	analyzed := git("rev-parse", "HEAD")
+	// another scope's change is on HEAD.
+	git("commit", "-q", "--allow-empty", "-m", "fix(other): cool fix")
+	head := git("rev-parse", "HEAD")
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(filepath.Join(dir, "sub")); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	cfg := config.NewWithTerminalIO(&config.Config{
+		VersionFiles: []config.VersionFile{{Path: "VERSION", Format: config.VersionFileRegex, Pattern: `\A(\S+)`}},
+	}, &config.TerminalIO{Stdout: &bytes.Buffer{}, Stderr: &bytes.Buffer{}})
+	rnr, err := New(cfg, gitcli.New(cfg, ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ver := &commit.Version{Version: semver.MustParse("1.3.0"), Commit: analyzed}
+	if err := rnr.UpdateVersionFiles(context.Background(), []*commit.Version{ver}); err != nil {
+		t.Fatal(err)
+	}
+
+	if b, _ := os.ReadFile(filepath.Join(dir, "VERSION")); string(b) != "1.3.0\n" {
+		t.Errorf("expected the repository's version file to be written, got %q", b)
+	}
+	if b, _ := os.ReadFile(filepath.Join(dir, "sub", "VERSION")); string(b) != "9.9.9\n" {
+		t.Errorf("expected the subdirectory's file to be left alone, got %q", b)
+	}
+	if ver.Commit == analyzed || ver.Commit == head {
+		t.Fatal("expected the release commit to be tagged")
+	}
+	if parent := git("rev-parse", ver.Commit+"^"); parent != head {
+		t.Errorf("expected the release commit to be created on HEAD %s, got parent %s", head, parent)
+	}
+	if files := git("show", "--name-only", "--format=", ver.Commit); files != "VERSION" {
+		t.Errorf("expected release commit to change VERSION, got %q", files)
+	}
+
+	commits, err := gitcli.New(cfg, "").ReadCommits(context.Background(), "-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vals := commits[0].TrailerValues(commit.ReleaseCommitTrailer); len(vals) != 1 || vals[0] != "v1.3.0" {
+		t.Errorf("expected the release commit to have a %s trailer, got %q", commit.ReleaseCommitTrailer, vals)
+	}
+}
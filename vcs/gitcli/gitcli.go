@@ -68,6 +68,14 @@ func (g *Git) GetMainBranch(ctx context.Context, candidates []string) (string, e
 	return "", fmt.Errorf("no matching release branch of candidates: %q", candidates)
 }
 
+func (g *Git) Root(ctx context.Context) (string, error) {
+	b, err := g.call(ctx, []string{"rev-parse", "--show-toplevel"})
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(b)), nil
+}
+
 func (g *Git) CurrentCommit(ctx context.Context) (string, error) {
 	args := []string{"rev-parse", "HEAD"}
 	b, err := g.call(ctx, args)
@@ -78,6 +86,18 @@ func (g *Git) CurrentCommit(ctx context.Context) (string, error) {
 	return strings.TrimSpace(string(b)), nil
 }
 
+// ResolveRef returns the id of the commit ref points to. Annotated tags are
+// resolved to their commit.
+func (g *Git) ResolveRef(ctx context.Context, ref string) (string, error) {
+	args := []string{"rev-parse", "--verify", "--quiet", ref + "^{commit}"}
+	b, err := g.call(ctx, args)
+	if err != nil {
+		return "", vcs.NotFoundError{Ref: ref}
+	}
+
+	return strings.TrimSpace(string(b)), nil
+}
+
 func (g *Git) BranchContains(ctx context.Context, commit, branch string) (bool, error) {
 	args := []string{"branch", "--contains", commit, "--list", branch}
 	b, err := g.call(ctx, args)
@@ -108,6 +128,9 @@ func (g *Git) Push(ctx context.Context, upstream, ref string, opts vcs.PushOpts)
 	if opts.FollowTags {
 		args = append(args, "--follow-tags")
 	}
+	if opts.Force {
+		args = append(args, "--force")
+	}
 	if g.cfg.InCI {
 		args = append(args, "--atomic")
 	}
@@ -131,49 +154,41 @@ const expectedLogParts = 10
 func (g *Git) ReadCommits(ctx context.Context, query string) ([]*model.Commit, error) {
 	// TODO chunk the read. use --max-count and the commit id as a cursor
 	args := []string{
-		"log", "--pretty=tformat:_START_%H_SEP_%aN_SEP_%ae_SEP_%ai_SEP_%cN_SEP_%ce_SEP_%ci_SEP_%s_SEP_%s_SEP_%b_END_", query,
+		"log", "-z", "--pretty=tformat:_START_%H_SEP_%aN_SEP_%ae_SEP_%ai_SEP_%cN_SEP_%ce_SEP_%ci_SEP_%s_SEP_%s_SEP_%B_END_",
 	}
+	// changed files are only read when they can be matched against.
+	readFiles := g.cfg.ReadsPaths()
+	if readFiles {
+		args = append(args, "--name-only")
+	}
+	args = append(args, query)
 	b, err := g.call(ctx, args)
 	if err != nil {
 		return nil, err
 	}
 
+	// -z terminates each commit, and each changed file listed after it, with
+	// a NUL, so multi-line fields such as the body can be read as they are.
 	var commits []*model.Commit
-	scanner := bufio.NewScanner(bytes.NewReader(b))
-	for scanner.Scan() {
-		s := scanner.Text()
-		parts := strings.Split(s, "_SEP_")
-		if len(parts) != expectedLogParts {
-			return nil, fmt.Errorf("gitcli: expected %d parts from git log, got %d", expectedLogParts, len(parts))
-		}
-
-		commitID := parts[0]
-		if !strings.HasPrefix(commitID, "_START_") {
-			return nil, fmt.Errorf("gitcli: unexpected git log line: %q", s)
+	for _, s := range strings.Split(string(b), "\x00") {
+		s = strings.TrimPrefix(s, "\n")
+		if s == "" {
+			continue
 		}
-		commitID = strings.TrimPrefix(commitID, "_START_")
-
-		// body can be multiple lines.
-		var body string
-		bodypart := parts[len(parts)-1]
-		if strings.HasSuffix(bodypart, "_END_") {
-			body = strings.TrimSuffix(bodypart, "_END_")
-		} else {
-			var bodyb strings.Builder
-			bodyb.WriteString(bodypart)
-			bodyb.WriteString("\n")
-			for scanner.Scan() {
-				bodyline := scanner.Text()
-				if strings.HasSuffix(bodyline, "_END_") {
-					if trimmed := strings.TrimSpace(strings.TrimSuffix(bodyline, "_END_")); trimmed != "" {
-						bodyb.WriteString(trimmed)
-					}
-					break
-				}
-				bodyb.WriteString(bodyline)
-				bodyb.WriteString("\n")
+		if !strings.HasPrefix(s, "_START_") || !strings.HasSuffix(s, "_END_") {
+			// --name-only lists changed files after each commit
+			if !readFiles || len(commits) == 0 {
+				return nil, fmt.Errorf("gitcli: unexpected git log entry: %q", s)
 			}
-			body = bodyb.String()
+			last := commits[len(commits)-1]
+			last.Files = append(last.Files, s)
+			continue
+		}
+		s = strings.TrimSuffix(strings.TrimPrefix(s, "_START_"), "_END_")
+		// the raw message is last, so it may contain the separator.
+		parts := strings.SplitN(s, "_SEP_", expectedLogParts)
+		if len(parts) != expectedLogParts {
+			return nil, fmt.Errorf("gitcli: expected %d parts from git log, got %d", expectedLogParts, len(parts))
 		}
 
 		authorDateStr := parts[3]
@@ -188,7 +203,7 @@ func (g *Git) ReadCommits(ctx context.Context, query string) ([]*model.Commit, e
 		}
 
 		commits = append(commits, &model.Commit{
-			ID:             commitID,
+			ID:             parts[0],
 			Author:         parts[1],
 			AuthorEmail:    parts[2],
 			AuthorDate:     authorDate,
@@ -197,12 +212,48 @@ func (g *Git) ReadCommits(ctx context.Context, query string) ([]*model.Commit, e
 			CommitterDate:  committerDate,
 			Subject:        parts[7],
 			Ref:            parts[8],
-			Body:           body,
+			Body:           messageBody(parts[9]),
+			Message:        parts[9],
 		})
 	}
 	return commits, nil
 }
 
+// messageBody returns the body of a raw commit message, as git's %b does: the
+// message after the subject paragraph.
+func messageBody(msg string) string {
+	i := strings.Index(msg, "\n\n")
+	if i < 0 {
+		return ""
+	}
+	return strings.TrimLeft(msg[i+2:], "\n")
+}
+
+// Commit commits the files at paths, returning the new commit id.
+func (g *Git) Commit(ctx context.Context, message string, paths []string) (string, error) {
+	if g.cfg.InCI {
+		// TODO these should be configurable via flags, env vars, etc
+		if err := g.setAuthor(ctx, "tunk", "cool+release@example.com"); err != nil {
+			return "", err
+		}
+	}
+	addArgs := append([]string{"add", "--"}, paths...)
+	commitArgs := []string{"commit", "-m", message, "--"}
+	commitArgs = append(commitArgs, paths...)
+	if g.cfg.Dryrun {
+		g.cfg.Printf("+ git %s (dryrun)", ArgsString(addArgs))
+		g.cfg.Printf("+ git %s (dryrun)", ArgsString(commitArgs))
+		return "", nil
+	}
+	if _, err := g.call(ctx, addArgs); err != nil {
+		return "", err
+	}
+	if _, err := g.call(ctx, commitArgs); err != nil {
+		return "", err
+	}
+	return g.CurrentCommit(ctx)
+}
+
 func (g *Git) CreateTag(ctx context.Context, commit, tag string, opts vcs.TagOpts) error {
 	if opts.Message == "" {
 		opts.Message = tag
@@ -237,12 +288,15 @@ func (g *Git) CreateTag(ctx context.Context, commit, tag string, opts vcs.TagOpt
 	args := []string{
 		"tag", "-a", tag,
 	}
+	if opts.Force {
+		args = append(args, "-f")
+	}
 	if commit != "" {
 		args = append(args, commit)
 	}
 	stdoutfd := os.Stdout.Fd()
 	istty := isatty.IsTerminal(stdoutfd)
-	if !g.cfg.InCI && !g.cfg.NoEdit && istty {
+	if !g.cfg.InCI && !g.cfg.NoEdit && !opts.NoEdit && istty {
 		args = append(args, "-e")
 	}
 	args = append(args, "-F", tmpfile.Name())
@@ -286,6 +340,24 @@ func (g *Git) ReadTags(ctx context.Context, query string) ([]string, error) {
 	return tags, nil
 }
 
+// ReadMergedTags reads the tags matching query that are reachable from ref.
+func (g *Git) ReadMergedTags(ctx context.Context, ref, query string) ([]string, error) {
+	args := []string{"tag", "--merged", ref}
+	if query != "" {
+		args = append(args, "-l", query)
+	}
+	b, err := g.call(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	var tags []string
+	scanner := bufio.NewScanner(bytes.NewBuffer(b))
+	for scanner.Scan() {
+		tags = append(tags, scanner.Text())
+	}
+	return tags, nil
+}
+
 func (g *Git) setAuthor(ctx context.Context, author, email string) error {
 	userArgs := []string{"config", "--local", "user.name", author}
 	emailArgs := []string{"config", "--local", "user.email", email}
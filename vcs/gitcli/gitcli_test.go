@@ -0,0 +1,92 @@
+package gitcli
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jeffrom/tunk/config"
+)
+
+func TestReadCommits(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+	dir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %q: %v: %s", args, err, out)
+		}
+	}
+	git("init", "-q")
+	git("config", "user.email", "tunk-test@example.com")
+	git("config", "user.name", "tunk-test")
+	git("commit", "-q", "--allow-empty", "-m", "initial commit")
+	if err := os.MkdirAll(filepath.Join(dir, "api"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "api", "cool.proto"), []byte("cool\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	git("add", ".")
+	message := "feat: cool thing\n\n_START_ of the body\n_SEP_ and _END_"
+	git("commit", "-q", "-m", message)
+
+	tio := &config.TerminalIO{Stdout: &bytes.Buffer{}, Stderr: &bytes.Buffer{}}
+	tcs := []struct {
+		name        string
+		cfg         *config.Config
+		expectFiles []string
+	}{
+		{
+			name: "no-paths",
+			cfg:  &config.Config{},
+		},
+		{
+			name:        "rule-paths",
+			cfg:         &config.Config{Rules: []config.Rule{{Condition: config.Condition{Paths: []string{"api/**"}}, MinType: "minor"}}},
+			expectFiles: []string{"api/cool.proto"},
+		},
+		{
+			name:        "ignore-paths",
+			cfg:         &config.Config{Ignore: []config.Condition{{Paths: []string{"docs/**"}}}},
+			expectFiles: []string{"api/cool.proto"},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			g := New(config.NewWithTerminalIO(tc.cfg, tio), dir)
+			commits, err := g.ReadCommits(context.Background(), "HEAD")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(commits) != 2 {
+				t.Fatalf("expected 2 commits, got %d", len(commits))
+			}
+			c := commits[0]
+			if c.Subject != "feat: cool thing" {
+				t.Errorf("expected subject %q, got %q", "feat: cool thing", c.Subject)
+			}
+			if expect := "_START_ of the body\n_SEP_ and _END_\n"; c.Body != expect {
+				t.Errorf("expected body %q, got %q", expect, c.Body)
+			}
+			if c.Message != message+"\n" {
+				t.Errorf("expected message %q, got %q", message+"\n", c.Message)
+			}
+			if strings.Join(c.Files, ",") != strings.Join(tc.expectFiles, ",") {
+				t.Errorf("expected files %q, got %q", tc.expectFiles, c.Files)
+			}
+			if len(commits[1].Files) != 0 {
+				t.Errorf("expected no files for the empty commit, got %q", commits[1].Files)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package vcs
 
 import (
 	"context"
+	"regexp"
 	"strings"
 	"time"
 
@@ -12,6 +13,7 @@ type Mock struct {
 	t       time.Time
 	tags    []string
 	commits []*model.Commit
+	branch  string
 }
 
 func NewMock() *Mock {
@@ -24,6 +26,13 @@ func (m *Mock) SetTags(tags ...string) *Mock {
 	m.tags = tags
 	return m
 }
+
+// SetBranch sets the current branch. The default is "main".
+func (m *Mock) SetBranch(branch string) *Mock {
+	m.branch = branch
+	return m
+}
+
 func (m *Mock) SetCommits(commits ...*model.Commit) *Mock {
 	finalCommits := make([]*model.Commit, len(commits))
 	for i, commit := range commits {
@@ -46,6 +55,11 @@ func (m *Mock) Push(ctx context.Context, upstream, ref string, opts PushOpts) er
 	return nil
 }
 
+// Commit returns a new commit id without changing the mock's commits.
+func (m *Mock) Commit(ctx context.Context, message string, paths []string) (string, error) {
+	return "feedface", nil
+}
+
 func (m *Mock) CreateTag(ctx context.Context, commit, tag string, opts TagOpts) error {
 	return nil
 }
@@ -65,6 +79,12 @@ func (m *Mock) ReadTags(ctx context.Context, query string) ([]string, error) {
 	return tags, nil
 }
 
+// ReadMergedTags returns the same tags as ReadTags. All of the mock's tags are
+// reachable from any ref.
+func (m *Mock) ReadMergedTags(ctx context.Context, ref, query string) ([]string, error) {
+	return m.ReadTags(ctx, query)
+}
+
 func (m *Mock) ReadCommits(ctx context.Context, query string) ([]*model.Commit, error) {
 	return m.commits, nil
 }
@@ -73,15 +93,34 @@ func (m *Mock) BranchContains(ctx context.Context, commit, branch string) (bool,
 	return true, nil
 }
 
+// Root returns an empty directory, so paths are relative to the working
+// directory.
+func (m *Mock) Root(ctx context.Context) (string, error) {
+	return "", nil
+}
+
 func (m *Mock) CurrentCommit(ctx context.Context) (string, error) {
 	return "deadbeef", nil
 }
 
+// ResolveRef resolves the mock's tags to the same commit as CurrentCommit.
+func (m *Mock) ResolveRef(ctx context.Context, ref string) (string, error) {
+	for _, t := range m.tags {
+		if t == ref {
+			return "deadbeef", nil
+		}
+	}
+	return "", NotFoundError{Ref: ref}
+}
+
 func (m *Mock) GetMainBranch(ctx context.Context, candidates []string) (string, error) {
 	return "main", nil
 }
 
 func (m *Mock) CurrentBranch(ctx context.Context) (string, error) {
+	if m.branch != "" {
+		return m.branch, nil
+	}
 	return "main", nil
 }
 
@@ -89,23 +128,13 @@ func (m *Mock) ReadNameFromRemoteURL(ctx context.Context, upstream string) (stri
 	return "tunk", nil
 }
 
+// globMatches matches s against a git tag glob, where "*" matches any
+// characters, including "/".
 func globMatches(s string, glob string) bool {
 	parts := strings.Split(glob, "*")
-	remaining := s
-	for {
-		if len(parts) == 0 {
-			break
-		}
-		part := parts[0]
-		parts = parts[1:]
-
-		if !strings.HasPrefix(remaining, part) {
-			return false
-		}
-		remaining = strings.TrimPrefix(remaining, part)
-	}
-	if len(glob) > 0 && glob[len(glob)-1] == '*' {
-		return true
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
 	}
-	return remaining == ""
+	re := regexp.MustCompile("^" + strings.Join(parts, ".*") + "$")
+	return re.MatchString(s)
 }
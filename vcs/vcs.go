@@ -23,23 +23,34 @@ type Interface interface {
 	Fetch(ctx context.Context, upstream, ref string) error
 	Push(ctx context.Context, upstream, ref string, opts PushOpts) error
 	ReadCommits(ctx context.Context, query string) ([]*model.Commit, error)
+	Commit(ctx context.Context, message string, paths []string) (string, error)
 	CreateTag(ctx context.Context, commit, tag string, opts TagOpts) error
 	DeleteTag(ctx context.Context, commit, tag string) error
 	ReadTags(ctx context.Context, query string) ([]string, error)
+	ReadMergedTags(ctx context.Context, ref, query string) ([]string, error)
 	GetMainBranch(ctx context.Context, candidates []string) (string, error)
 	CurrentBranch(ctx context.Context) (string, error)
 	BranchContains(ctx context.Context, commit, branch string) (bool, error)
 	CurrentCommit(ctx context.Context) (string, error)
+	ResolveRef(ctx context.Context, ref string) (string, error)
 	ReadNameFromRemoteURL(ctx context.Context, upstream string) (string, error)
+	// Root returns the top-level directory of the repository.
+	Root(ctx context.Context) (string, error)
 }
 
 type TagOpts struct {
 	Message     string
 	Author      string
 	AuthorEmail string
+	// Force replaces an existing tag of the same name.
+	Force bool
+	// NoEdit skips editing the message, regardless of configuration.
+	NoEdit bool
 }
 
 type PushOpts struct {
 	Tags       bool
 	FollowTags bool
+	// Force replaces the remote ref, such as a moved tag.
+	Force bool
 }